@@ -1,17 +1,67 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"lazynginx/pkg/app"
+	"lazynginx/pkg/cli"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/gui"
 	"os"
+	"runtime/debug"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = ""
+
 func main() {
+	configPath := flag.String("config", "", "path to config file (default "+config.DefaultPath()+")")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	output := flag.String("output", "text", "output of commands: text or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: lazynginx [flags] [command]\n\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s", cli.Usage)
+	}
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println("lazynginx " + currentVersion())
+		return
+	}
+
+	if err := config.Load(*configPath); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Subcommands run without the UI, for scripts and SSH one-liners
+	if flag.NArg() > 0 {
+		os.Exit(cli.Run(flag.Args(), *output, os.Stdout, os.Stderr))
+	}
+
+	if err := gui.ApplyTheme(config.Get().Theme); err != nil {
+		fmt.Printf("Error: invalid config: %v\n", err)
+		os.Exit(1)
+	}
+
 	p := tea.NewProgram(app.NewModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
 	}
 }
+
+// currentVersion returns the release version, or the module version for
+// go install builds
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"lazynginx/pkg/commands"
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/logs"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// onAccessLog reports whether the access log view, or a site's access log, is selected
+func (m Model) onAccessLog() bool {
+	return (m.MainCursor == 5 && m.SubCursor == 1) || m.siteLog() == "access"
+}
+
+// accessLogShown reports whether the details panel shows the access log, rather than a followed log
+func (m Model) accessLogShown() bool {
+	return m.onAccessLog() && m.AccessLog != nil && m.Follower == nil
+}
+
+// GetLogFilter returns the access log filter, whether it is being typed and
+// how many records it matches (or why it is invalid)
+func (m Model) GetLogFilter() (filter string, typing bool, status string) {
+	if !m.accessLogShown() {
+		return "", false, ""
+	}
+	matched, err := m.filterRecords()
+	switch {
+	case err != nil:
+		status = err.Error()
+	case m.LogFilter == "":
+		status = "type an expression"
+	default:
+		status = fmt.Sprintf("%d of %d records", len(matched), len(m.filterSource()))
+		if m.AccessLog.Span.Empty() && !m.accessRecordsRead() {
+			status += ", reading the log..."
+		}
+	}
+	return m.LogFilter, m.FilterTyping, status
+}
+
+// accessRecordsRead reports whether the end of the shown access log was
+// read for the filter
+func (m Model) accessRecordsRead() bool {
+	return m.AccessRecords != nil && m.AccessRecords.Path == m.AccessLog.Path && m.AccessRecords.Err == nil
+}
+
+// filterSource returns the records the filter searches: the end of the log
+// once it was read, the lines shown until then. A time span is searched
+// as it was read.
+func (m Model) filterSource() []*logs.Record {
+	if m.AccessLog.Span.Empty() && m.accessRecordsRead() {
+		return m.AccessRecords.Records
+	}
+	return m.AccessLog.Records
+}
+
+// filterRecords returns the access log records matching the filter
+func (m Model) filterRecords() ([]*logs.Record, error) {
+	filter, err := logs.ParseFilter(m.LogFilter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	records := m.filterSource()
+	if filter.Empty() {
+		return records, nil
+	}
+
+	var matched []*logs.Record
+	for _, r := range records {
+		if filter.Match(r) {
+			matched = append(matched, r)
+		}
+	}
+	return matched, nil
+}
+
+// filterShownRecords is how many of the newest matching records the filtered view lists
+const filterShownRecords = 5000
+
+// renderAccessLog shows the access log in the details panel. With a filter
+// set it shows the matching records of the end of the log instead.
+func (m *Model) renderAccessLog() {
+	a := m.AccessLog
+	heading := logHeading(logTitle("access", a.Path, a.Site, a.Inherited), a.Lines, a.Span, a.Truncated)
+	header := fmt.Sprintf("%s:\nFormat: %s (%d lines parsed, %d did not match)\n", heading, a.Format, len(a.Records), a.Skipped)
+
+	matched, err := m.filterRecords()
+	switch {
+	case m.LogFilter == "":
+		m.DetailOutput = header + "\n" + a.Output
+	case err != nil:
+		m.DetailOutput = header + "Filter: " + m.LogFilter + "\n" + gui.ErrorStyle.Render(err.Error()) + "\n\n" + a.Output
+	default:
+		searched := fmt.Sprintf("the last %d lines", a.Lines)
+		switch r := m.AccessRecords; {
+		case !a.Span.Empty():
+			searched = "the time span"
+		case m.accessRecordsRead() && r.Partial:
+			searched = fmt.Sprintf("the last %dMB of the log", commands.FilterReadBytes/1024/1024)
+		case m.accessRecordsRead():
+			searched = "the whole log"
+		case r != nil && r.Path == a.Path:
+			searched += ", the log could not be read: " + r.Err.Error()
+		default:
+			searched += ", reading the log..."
+		}
+		count, note := len(matched), ""
+		if count > filterShownRecords {
+			note = fmt.Sprintf(", showing the newest %d", filterShownRecords)
+			matched = matched[len(matched)-filterShownRecords:]
+		}
+
+		lines := make([]string, len(matched))
+		for i, r := range matched {
+			lines[i] = r.Raw
+		}
+		m.DetailOutput = header + fmt.Sprintf("Filter: %s (%d of %d records match in %s%s)\n\n", m.LogFilter, count, len(m.filterSource()), searched, note) + strings.Join(lines, "\n")
+	}
+	m.DetailScroll = 0
+}
+
+// startFilter opens the filter prompt with the current filter
+func (m *Model) startFilter() {
+	m.FilterTyping = true
+	m.FilterBefore = m.LogFilter
+}
+
+// handleFilterInput handles keys while the filter prompt is open. The view
+// is filtered as the expression is typed.
+func (m Model) handleFilterInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		// Back to the filter in place before the prompt opened
+		m.FilterTyping = false
+		m.LogFilter = m.FilterBefore
+	case tea.KeyEnter:
+		m.FilterTyping = false
+		return m, nil
+	case tea.KeyCtrlU:
+		m.LogFilter = ""
+	case tea.KeyBackspace:
+		if m.LogFilter != "" {
+			_, size := utf8.DecodeLastRuneInString(m.LogFilter)
+			m.LogFilter = m.LogFilter[:len(m.LogFilter)-size]
+		}
+	case tea.KeySpace:
+		m.LogFilter += " "
+	case tea.KeyRunes:
+		m.LogFilter += string(msg.Runes)
+	default:
+		return m, nil
+	}
+
+	if m.accessLogShown() {
+		m.renderAccessLog()
+	}
+	return m, nil
+}
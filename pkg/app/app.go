@@ -1,7 +1,15 @@
 package app
 
 import (
+	"fmt"
 	"lazynginx/pkg/commands"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/nginxconf"
+	"lazynginx/pkg/textedit"
+	"lazynginx/pkg/utils"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -25,10 +33,54 @@ type Model struct {
 	CurrentConfigPath string
 	CurrentConfigType string
 	CurrentSiteName   string
-	MainScroll        int  // Scroll position for main menu
-	SubScroll         int  // Scroll position for submenu
-	DetailScroll      int  // Scroll position for details panel
-	IsAdmin           bool // Whether running with admin/root privileges
+	MainScroll        int                    // Scroll position for main menu
+	SubScroll         int                    // Scroll position for submenu
+	DetailScroll      int                    // Scroll position for details panel
+	IsAdmin           bool                   // Whether running with admin/root privileges
+	SiteStates        map[string]bool        // Enabled (true) / disabled (false) state of each site
+	PendingWrite      *commands.PendingWrite // Generated config waiting in the preview modal
+	Preview           string                 // Generated file or diff shown in the preview modal
+	ModalScroll       int                    // Scroll position for the preview modal
+	Follower          *logs.Follower         // Log being followed, nil when not following
+	FollowTitle       string
+	FollowBuffer      *logs.Ring             // Last lines of the followed log
+	FollowPaused      bool                   // New lines are buffered but not shown
+	TrafficWindow     int                    // Index into commands.TrafficWindows
+	Traffic           *commands.TrafficMsg   // Last statistics shown in the Traffic view
+	TrafficTick       int                    // Sequence of the running refresh tick
+	ErrorLog          *commands.ErrorLogMsg  // Parsed error log shown in the details panel
+	ErrorLevel        int                    // Index into errorLevels
+	ErrorsGrouped     bool                   // Repeated messages are listed once with a count
+	ErrorCursor       int                    // Selected entry of the error log view
+	Search            string                 // Text searched for in the details panel
+	SearchRegex       bool                   // Search is a regular expression
+	SearchTyping      bool                   // The search prompt is open
+	SearchMatch       int                    // Index of the current match
+	SearchOrigin      int                    // Details scroll position when the search started
+	AccessLog         *commands.AccessLogMsg // Parsed access log shown in the details panel
+	LogFilter         string                 // Filter expression applied to the access log
+	FilterTyping      bool                   // The filter prompt is open
+	FilterBefore      string                 // Filter to go back to when the prompt is cancelled
+	LogFiles          []logs.LogFile         // Log and rotated copies offered by the log file picker
+	LogFilesKind      string                 // "error" or "access"
+	SiteLog           string                 // "error" or "access" while a site's log is shown, "" otherwise
+	SiteLogSite       string                 // Site whose log is shown
+	SiteLogPath       string                 // File of the site log, followed with [f]
+	Test              *commands.TestResult   // Last nginx -t result shown in the details panel
+	DiagnosticCursor  int                    // Selected error or warning of the nginx -t result
+	ConfigPositions   []nginxconf.Position   // File line shown on each line of the config view
+	Editing           *textedit.Buffer       // File open in the built-in editor, nil when closed
+	EditingPath       string
+	EditingNote       string                     // Outcome of the last save, shown under the editor
+	EditingSaved      bool                       // A save was kept, so the view below reloads on close
+	ConfigView        *commands.ConfigViewMsg    // Configuration shown in the details panel
+	ConfigLines       []string                   // Its lines with syntax colors
+	ConfigBlocks      []nginxconf.Block          // Its blocks that fold
+	ConfigCursor      int                        // Selected row of the config view, where folds and edits apply
+	Folded            map[int]bool               // Folded blocks by the line they start on
+	LineNumbers       bool                       // Config views show line numbers
+	PendingKey        string                     // First key of a two-key command, "z" for folds
+	AccessRecords     *commands.AccessRecordsMsg // Records of the end of the access log, searched by the filter
 }
 
 // Implement interface methods for commands.ModelInterface
@@ -56,6 +108,100 @@ func (m Model) GetSubScroll() int             { return m.SubScroll }
 func (m Model) GetDetailScroll() int          { return m.DetailScroll }
 func (m Model) GetIsAdmin() bool              { return m.IsAdmin }
 
+// GetSiteState returns whether a site is enabled, and false for known if its state wasn't loaded
+func (m Model) GetSiteState(siteName string) (enabled bool, known bool) {
+	enabled, known = m.SiteStates[siteName]
+	return enabled, known
+}
+
+// GetPreview returns the pending write shown in the preview modal
+func (m Model) GetPreview() (path string, preview string, overwrite bool) {
+	if m.PendingWrite == nil {
+		return "", "", false
+	}
+	return m.PendingWrite.Path, m.Preview, m.PendingWrite.Exists
+}
+
+func (m Model) GetModalScroll() int { return m.ModalScroll }
+
+// GetFollowing reports whether a log is being followed, and whether the follow is paused
+func (m Model) GetFollowing() (following bool, paused bool) {
+	return m.Follower != nil, m.FollowPaused
+}
+
+// GetErrorLogView reports whether the error log list is shown, with its severity filter and grouping
+func (m Model) GetErrorLogView() (shown bool, level string, grouped bool) {
+	return m.errorLogShown(), errorLevels[m.ErrorLevel], m.ErrorsGrouped
+}
+
+// GetTestView reports whether the nginx -t errors and warnings are listed,
+// and whether the selected one names a file to open
+func (m Model) GetTestView() (shown bool, hasFile bool) {
+	if !m.diagnosticsShown() {
+		return false, false
+	}
+	d := m.selectedDiagnostic()
+	return true, d != nil && d.File != ""
+}
+
+// GetLogFiles returns the files offered by the log file picker
+func (m Model) GetLogFiles() []logs.LogFile { return m.LogFiles }
+
+// stopFollow ends the current log follow, if any
+func (m *Model) stopFollow() {
+	if m.Follower != nil {
+		m.Follower.Stop()
+		m.Follower = nil
+		m.FollowPaused = false
+	}
+}
+
+// followsSelection reports whether following the log at path belongs to
+// the selected menu item: a log of the Logs menu or the selected site's log
+func (m Model) followsSelection(path string) bool {
+	if m.siteLog() != "" {
+		return path == m.SiteLogPath
+	}
+	if m.MainCursor != 5 {
+		return false
+	}
+	return (m.SubCursor == 0 && path == discovery.Get().ErrorLog) || (m.SubCursor == 1 && path == discovery.Get().AccessLog)
+}
+
+// renderFollow shows the followed log in the details panel, scrolled to the newest line
+func (m *Model) renderFollow() {
+	if m.FollowBuffer == nil {
+		return
+	}
+	lines := m.FollowBuffer.Lines()
+
+	state := "live"
+	if m.FollowPaused {
+		state = "paused"
+	}
+	header := fmt.Sprintf("%s - %s, %d lines buffered (max %d)\n\n", m.FollowTitle, state, len(lines), commands.FollowBufferLines)
+
+	// Error log lines are colored by severity
+	if m.onErrorLog() {
+		colored := make([]string, len(lines))
+		for i, line := range lines {
+			colored[i] = gui.ColorErrorLine(line)
+		}
+		lines = colored
+	}
+
+	m.DetailOutput = header + strings.Join(lines, "\n")
+	if !m.FollowPaused {
+		// Scroll to the newest line
+		m.DetailScroll = utils.Max(strings.Count(m.DetailOutput, "\n")+1-m.detailLines(), 0)
+	}
+}
+
+// detailLines returns how many lines of output the details panel shows
+func (m Model) detailLines() int {
+	return utils.Max(m.WindowHeight-6, 1)
+}
+
 // getAdminWarning returns the admin warning message if not admin
 func (m Model) getAdminWarning() string {
 	if !m.IsAdmin {
@@ -69,15 +215,28 @@ func (m Model) getAdminWarning() string {
 	return ""
 }
 
+// showOutput shows the text of a command result in the details panel,
+// replacing whatever config or log it showed
+func (m *Model) showOutput(output string) {
+	m.DetailOutput = output + m.getAdminWarning()
+	m.showSiteLog("", "", "")
+	m.Test = nil
+	m.CurrentConfigPath = ""
+	m.CurrentConfigType = ""
+	m.CurrentSiteName = ""
+	m.DetailScroll = 0 // Reset scroll on new content
+}
+
 func NewModel() Model {
 	subMenus := make(map[int][]string)
-	subMenus[0] = []string{"Check Status", "Test Configuration"}               // Status & Monitoring
+	subMenus[0] = []string{"Check Status", "Test Configuration", "Traffic"}    // Status & Monitoring
 	subMenus[1] = []string{"Start", "Stop", "Restart", "Reload Configuration"} // Service Control
 	subMenus[2] = []string{"Add site", "Loading sites..."}                     // Sites - populated dynamically
 	subMenus[3] = []string{"Add Reverse Proxy", "Loading reverse proxies..."}  // Reverse Proxies - populated dynamically
 	subMenus[4] = []string{}                                                   // Configuration - auto-loads config file
 	subMenus[5] = []string{"View Error Log", "View Access Log"}                // Logs
-	subMenus[6] = []string{"Exit Application"}                                 // Quit
+	subMenus[6] = []string{"Loading backups..."}                               // Backups - populated dynamically
+	subMenus[7] = []string{"Exit Application"}                                 // Quit
 
 	// Check for admin permissions
 	isAdmin := commands.IsAdmin()
@@ -101,21 +260,25 @@ func NewModel() Model {
 			"Reverse Proxies",
 			"Configuration",
 			"Logs",
+			"Backups",
 			"Quit",
 		},
-		SubMenus:     subMenus,
-		MainCursor:   0,
-		SubCursor:    0,
-		ActivePanel:  0,
-		Status:       "",
-		DetailOutput: initialDetail,
-		WindowWidth:  120,
-		WindowHeight: 30,
-		ShowModal:    false,
-		ModalType:    "",
-		ModalCursor:  0,
-		TextInput:    "",
-		IsAdmin:      isAdmin,
+		SubMenus:      subMenus,
+		MainCursor:    0,
+		SubCursor:     0,
+		ActivePanel:   0,
+		Status:        "",
+		DetailOutput:  initialDetail,
+		WindowWidth:   120,
+		WindowHeight:  30,
+		ShowModal:     false,
+		ModalType:     "",
+		ModalCursor:   0,
+		TextInput:     "",
+		IsAdmin:       isAdmin,
+		SiteStates:    make(map[string]bool),
+		ErrorsGrouped: true,
+		LineNumbers:   config.Get().LineNumbers,
 	}
 }
 
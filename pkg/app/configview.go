@@ -0,0 +1,174 @@
+package app
+
+import (
+	"lazynginx/pkg/commands"
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/nginxconf"
+	"lazynginx/pkg/utils"
+	"strings"
+)
+
+// foldable are the blocks a config view can fold
+var foldable = map[string]bool{
+	"http":     true,
+	"server":   true,
+	"location": true,
+	"upstream": true,
+}
+
+// showConfigView shows a configuration in the details panel, unfolded
+func (m *Model) showConfigView(msg commands.ConfigViewMsg) {
+	m.ConfigView = &msg
+	m.ConfigLines = gui.HighlightConfig(msg.Config)
+	m.ConfigBlocks = nil
+	for _, block := range nginxconf.Blocks(msg.Config) {
+		if foldable[block.Name] && block.End > block.Start {
+			m.ConfigBlocks = append(m.ConfigBlocks, block)
+		}
+	}
+	m.Folded = map[int]bool{}
+	// Start on the line the view points at
+	m.ConfigCursor = utils.Max(msg.Line-1, 0)
+	m.renderConfigView()
+}
+
+// configShown reports whether the details panel shows the config view
+func (m Model) configShown() bool {
+	return m.ConfigView != nil && m.CurrentConfigPath != ""
+}
+
+// configHeaderLines returns how many lines the header of the config view takes
+func (m Model) configHeaderLines() int {
+	return strings.Count(m.ConfigView.Header, "\n")
+}
+
+// configRows returns the lines the config view shows, leaving out the inside of folded blocks
+func (m Model) configRows() []gui.ConfigRow {
+	view := m.ConfigView
+	header := m.configHeaderLines()
+
+	var rows []gui.ConfigRow
+	for i := 0; i < len(m.ConfigLines); i++ {
+		row := gui.ConfigRow{Index: i}
+		if p := header + i; p < len(view.Positions) {
+			row.Number = view.Positions[p].Line
+		}
+		if m.Folded[i+1] {
+			end := m.foldEnd(i + 1)
+			row.Folded = end - (i + 1)
+			i = end - 1
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// foldEnd returns the last line hidden by folding the blocks starting on line
+func (m Model) foldEnd(line int) int {
+	end := line
+	for _, block := range m.ConfigBlocks {
+		if block.Start == line {
+			end = utils.Max(end, block.End)
+		}
+	}
+	return end
+}
+
+// renderConfigView shows the config view with its folds, cursor and line
+// numbers. The file positions follow the rows, so the editor still opens
+// where the user is looking.
+func (m *Model) renderConfigView() {
+	view := m.ConfigView
+	rows := m.configRows()
+	m.ConfigCursor = utils.Max(utils.Min(m.ConfigCursor, len(rows)-1), 0)
+	gutter := m.LineNumbers || view.Line > 0
+	m.DetailOutput = view.Header + gui.RenderConfig(m.ConfigLines, rows, m.ConfigCursor, gutter) + m.getAdminWarning()
+
+	header := m.configHeaderLines()
+	m.ConfigPositions = append([]nginxconf.Position(nil), view.Positions[:utils.Min(header, len(view.Positions))]...)
+	for _, row := range rows {
+		var p nginxconf.Position
+		if i := header + row.Index; i < len(view.Positions) {
+			p = view.Positions[i]
+		}
+		m.ConfigPositions = append(m.ConfigPositions, p)
+	}
+}
+
+// moveConfigCursor moves the cursor of the config view by delta rows,
+// scrolling to keep it in view
+func (m *Model) moveConfigCursor(delta int) {
+	m.ConfigCursor += delta
+	m.renderConfigView()
+
+	visible := m.detailLines()
+	line := m.configHeaderLines() + m.ConfigCursor
+	switch {
+	case m.ConfigCursor == 0:
+		m.DetailScroll = 0
+	case line < m.DetailScroll:
+		m.DetailScroll = line
+	case line >= m.DetailScroll+visible:
+		m.DetailScroll = line - visible + 1
+	}
+}
+
+// innermostBlock returns the innermost foldable block around line that
+// passes open, nil if there is none
+func (m Model) innermostBlock(line int, open func(block nginxconf.Block) bool) *nginxconf.Block {
+	var found *nginxconf.Block
+	for i, block := range m.ConfigBlocks {
+		// Blocks come before the blocks inside them, so the last one is innermost
+		if block.Start <= line && line <= block.End && open(block) {
+			found = &m.ConfigBlocks[i]
+		}
+	}
+	return found
+}
+
+// fold runs a fold command, the key typed after z: a toggles the fold at
+// the cursor, o opens it, c closes the block around it, M folds every block
+// and R opens them all
+func (m *Model) fold(key string) {
+	rows := m.configRows()
+	if len(rows) == 0 {
+		return
+	}
+	line := rows[m.ConfigCursor].Index + 1
+	every := func(nginxconf.Block) bool { return true }
+	unfolded := func(block nginxconf.Block) bool { return !m.Folded[block.Start] }
+
+	switch key {
+	case "a":
+		if m.Folded[line] {
+			delete(m.Folded, line)
+		} else if block := m.innermostBlock(line, every); block != nil {
+			m.Folded[block.Start] = true
+			line = block.Start
+		}
+	case "o":
+		delete(m.Folded, line)
+	case "c":
+		if block := m.innermostBlock(line, unfolded); block != nil {
+			m.Folded[block.Start] = true
+			line = block.Start
+		}
+	case "M":
+		for _, block := range m.ConfigBlocks {
+			m.Folded[block.Start] = true
+		}
+	case "R":
+		m.Folded = map[int]bool{}
+	default:
+		return
+	}
+
+	// The cursor stays on the line, or on the fold that now hides it
+	for i, row := range m.configRows() {
+		if row.Index+1 <= line && line <= row.Index+1+row.Folded {
+			m.ConfigCursor = i
+			break
+		}
+	}
+	m.moveConfigCursor(0)
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/nginxconf"
+	"lazynginx/pkg/utils"
+)
+
+// onTest reports whether Test Configuration is selected
+func (m Model) onTest() bool {
+	return m.MainCursor == 0 && m.SubCursor == 1
+}
+
+// diagnosticsShown reports whether the details panel lists the errors and
+// warnings of nginx -t, rather than the file opened from one of them
+func (m Model) diagnosticsShown() bool {
+	return m.onTest() && m.Test != nil && len(m.Test.Diagnostics) > 0 && m.Follower == nil && m.CurrentConfigPath == ""
+}
+
+// selectedDiagnostic returns the diagnostic under the cursor, nil if there is none
+func (m Model) selectedDiagnostic() *nginxconf.Diagnostic {
+	if m.Test == nil || m.DiagnosticCursor < 0 || m.DiagnosticCursor >= len(m.Test.Diagnostics) {
+		return nil
+	}
+	return &m.Test.Diagnostics[m.DiagnosticCursor]
+}
+
+// renderTest shows the nginx -t result in the details panel, scrolled to keep the cursor visible
+func (m *Model) renderTest() {
+	m.DiagnosticCursor = utils.Max(utils.Min(m.DiagnosticCursor, len(m.Test.Diagnostics)-1), 0)
+	m.DetailOutput = gui.RenderTest(*m.Test, m.DiagnosticCursor) + m.getAdminWarning()
+
+	visible := m.detailLines()
+	line := gui.TestHeaderLines + m.DiagnosticCursor
+	switch {
+	case m.DiagnosticCursor == 0:
+		m.DetailScroll = 0
+	case line < m.DetailScroll:
+		m.DetailScroll = line
+	case line >= m.DetailScroll+visible:
+		m.DetailScroll = line - visible + 1
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"lazynginx/pkg/nginxconf"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lineTemplates are the arguments that open {file} at {line}, by editor name
+var lineTemplates = map[string]string{
+	"vi":            "+{line} {file}",
+	"vim":           "+{line} {file}",
+	"nvim":          "+{line} {file}",
+	"gvim":          "+{line} {file}",
+	"nano":          "+{line} {file}",
+	"pico":          "+{line} {file}",
+	"emacs":         "+{line} {file}",
+	"emacsclient":   "+{line} {file}",
+	"micro":         "+{line} {file}",
+	"kak":           "+{line} {file}",
+	"code":          "-g {file}:{line}",
+	"code-insiders": "-g {file}:{line}",
+	"codium":        "-g {file}:{line}",
+	"cursor":        "-g {file}:{line}",
+	"subl":          "{file}:{line}",
+	"hx":            "{file}:{line}",
+	"helix":         "{file}:{line}",
+	"zed":           "{file}:{line}",
+}
+
+// fileArgs returns the editor arguments opening path at line. template is
+// the editor_line setting; when it is empty the template is chosen by the
+// editor's name. Editors without a known template open the file at the top.
+func fileArgs(editor string, template string, path string, line int) []string {
+	if line <= 0 {
+		return []string{path}
+	}
+	if template == "" {
+		name := strings.TrimSuffix(strings.ToLower(filepath.Base(editor)), ".exe")
+		template = lineTemplates[name]
+	}
+	if template == "" {
+		return []string{path}
+	}
+
+	if !strings.Contains(template, "{file}") {
+		template += " {file}"
+	}
+
+	// Split before substituting so a path with spaces stays one argument
+	var args []string
+	for _, field := range strings.Fields(template) {
+		field = strings.ReplaceAll(field, "{line}", strconv.Itoa(line))
+		args = append(args, strings.ReplaceAll(field, "{file}", path))
+	}
+	return args
+}
+
+// editPosition returns the file and line the editor opens from the config
+// shown in the details panel: the current search match, else the line under
+// the cursor. Line is 0 to open the file at the top.
+func (m Model) editPosition() nginxconf.Position {
+	if matches := m.searchMatches(); m.SearchMatch < len(matches) {
+		if p := m.positionAt(matches[m.SearchMatch].Line); p.Line > 0 {
+			return p
+		}
+	}
+	if m.configShown() {
+		if p := m.positionAt(m.configHeaderLines() + m.ConfigCursor); p.Line > 0 {
+			return p
+		}
+	}
+	return nginxconf.Position{File: m.CurrentConfigPath}
+}
+
+// positionAt returns the file position shown on a line of the details panel
+func (m Model) positionAt(line int) nginxconf.Position {
+	if line < 0 || line >= len(m.ConfigPositions) {
+		return nginxconf.Position{}
+	}
+	return m.ConfigPositions[line]
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		editor   string
+		template string
+		path     string
+		line     int
+		want     []string
+	}{
+		{"vim", "vim", "", "/etc/nginx/nginx.conf", 12, []string{"+12", "/etc/nginx/nginx.conf"}},
+		{"full path to nvim", "/usr/bin/nvim", "", "/etc/nginx/nginx.conf", 3, []string{"+3", "/etc/nginx/nginx.conf"}},
+		{"code", "code", "", "/etc/nginx/nginx.conf", 7, []string{"-g", "/etc/nginx/nginx.conf:7"}},
+		{"subl", "subl", "", "/etc/nginx/nginx.conf", 1, []string{"/etc/nginx/nginx.conf:1"}},
+		{"unknown editor", "ed", "", "/etc/nginx/nginx.conf", 12, []string{"/etc/nginx/nginx.conf"}},
+		{"no line", "vim", "", "/etc/nginx/nginx.conf", 0, []string{"/etc/nginx/nginx.conf"}},
+		{"template from the config", "ed", "--line {line} {file}", "/etc/nginx/nginx.conf", 5, []string{"--line", "5", "/etc/nginx/nginx.conf"}},
+		{"template without {file}", "ed", "+{line}", "/etc/nginx/nginx.conf", 5, []string{"+5", "/etc/nginx/nginx.conf"}},
+		{"path with spaces", "vim", "", "/srv/my sites/site.conf", 2, []string{"+2", "/srv/my sites/site.conf"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileArgs(tt.editor, tt.template, tt.path, tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fileArgs(%q, %q, %q, %d) = %q, want %q", tt.editor, tt.template, tt.path, tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/utils"
+)
+
+// errorLevels are the severity filters the error log view cycles through, "" shows all
+var errorLevels = []string{"", "warn", "error", "crit"}
+
+// onErrorLog reports whether the error log view, or a site's error log, is selected
+func (m Model) onErrorLog() bool {
+	return (m.MainCursor == 5 && m.SubCursor == 0) || m.siteLog() == "error"
+}
+
+// errorLogShown reports whether the details panel shows the error log list,
+// rather than a followed log or the config opened from an entry
+func (m Model) errorLogShown() bool {
+	return m.onErrorLog() && m.ErrorLog != nil && m.Follower == nil && m.CurrentConfigPath == ""
+}
+
+// errorItems returns the entries of the error log view after the severity
+// filter: one group per message when grouped, one per line otherwise
+func (m Model) errorItems() []*logs.ErrorGroup {
+	if m.ErrorLog == nil {
+		return nil
+	}
+	level := errorLevels[m.ErrorLevel]
+	if m.ErrorsGrouped {
+		return logs.GroupErrors(m.ErrorLog.Entries, level)
+	}
+
+	var items []*logs.ErrorGroup
+	for _, e := range m.ErrorLog.Entries {
+		if level == "" || logs.LevelRank(e.Level) >= logs.LevelRank(level) {
+			items = append(items, &logs.ErrorGroup{Level: e.Level, Message: e.Message, Server: e.Server, Upstream: logs.UpstreamOrigin(e.Upstream), Count: 1, First: e.Time, Last: e.Time, Latest: e})
+		}
+	}
+	return items
+}
+
+// selectedError returns the entry under the cursor, nil if there is none
+func (m Model) selectedError() *logs.ErrorEntry {
+	items := m.errorItems()
+	if m.ErrorCursor < 0 || m.ErrorCursor >= len(items) {
+		return nil
+	}
+	return items[m.ErrorCursor].Latest
+}
+
+// renderErrorLog shows the error log in the details panel, scrolled to keep the cursor visible
+func (m *Model) renderErrorLog() {
+	items := m.errorItems()
+	m.ErrorCursor = utils.Max(utils.Min(m.ErrorCursor, len(items)-1), 0)
+
+	e := m.ErrorLog
+	m.DetailOutput = gui.RenderErrorLog(logHeading(logTitle("error", e.Path, e.Site, e.Inherited), e.Lines, e.Span, e.Truncated), e.Skipped, items, m.ErrorsGrouped, errorLevels[m.ErrorLevel], m.ErrorCursor)
+
+	visible := m.detailLines()
+	line := gui.ErrorLogHeaderLines + m.ErrorCursor
+	switch {
+	case m.ErrorCursor == 0:
+		m.DetailScroll = 0
+	case line < m.DetailScroll:
+		m.DetailScroll = line
+	case line >= m.DetailScroll+visible:
+		m.DetailScroll = line - visible + 1
+	}
+}
@@ -2,6 +2,9 @@ package app
 
 import (
 	"lazynginx/pkg/commands"
+	"lazynginx/pkg/logs"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -13,6 +16,26 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.ShowModal = false
 		m.ModalType = ""
 		m.TextInput = ""
+		m.PendingWrite = nil
+		return m, nil
+
+	case "pgdown", "ctrl+d":
+		// Scroll the preview modal
+		if m.ModalType == "confirm-write" {
+			m.ModalScroll += 10
+			if lines := strings.Count(m.Preview, "\n") + 1; m.ModalScroll > lines-1 {
+				m.ModalScroll = lines - 1
+			}
+		}
+		return m, nil
+
+	case "pgup", "ctrl+u":
+		if m.ModalType == "confirm-write" {
+			m.ModalScroll -= 10
+			if m.ModalScroll < 0 {
+				m.ModalScroll = 0
+			}
+		}
 		return m, nil
 
 	case "up", "k":
@@ -20,7 +43,8 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.ModalType == "custom-input" || m.ModalType == "laravel-input" ||
 			m.ModalType == "static-input" || m.ModalType == "vanilla-php-input" ||
 			m.ModalType == "proxy-location-input" || m.ModalType == "proxy-location-input-lb" ||
-			m.ModalType == "proxy-host-input" || m.ModalType == "proxy-host-input-lb" {
+			m.ModalType == "proxy-host-input" || m.ModalType == "proxy-host-input-lb" ||
+			m.ModalType == "log-span" {
 			// For text input modals, let these keys fall through to default handler
 			if msg.String() == "k" {
 				key := msg.String()
@@ -34,10 +58,22 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.ModalCursor--
 		} else if m.ModalType == "confirm-delete-site" && m.ModalCursor > 0 {
 			m.ModalCursor--
+		} else if m.ModalType == "confirm-reload" && m.ModalCursor > 0 {
+			m.ModalCursor--
+		} else if m.ModalType == "confirm-discard" && m.ModalCursor > 0 {
+			m.ModalCursor--
+		} else if m.ModalType == "confirm-restore" && m.ModalCursor > 0 {
+			m.ModalCursor--
+		} else if m.ModalType == "confirm-write" && m.ModalCursor > 0 {
+			m.ModalCursor--
 		} else if m.ModalType == "site-type" && m.ModalCursor > 0 {
 			m.ModalCursor--
 		} else if m.ModalType == "proxy-type" && m.ModalCursor > 0 {
 			m.ModalCursor--
+		} else if m.ModalType == "log-file" && m.ModalCursor > 0 {
+			m.ModalCursor--
+		} else if m.ModalType == "site-logs" && m.ModalCursor > 0 {
+			m.ModalCursor--
 		}
 		return m, nil
 
@@ -46,7 +82,8 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.ModalType == "custom-input" || m.ModalType == "laravel-input" ||
 			m.ModalType == "static-input" || m.ModalType == "vanilla-php-input" ||
 			m.ModalType == "proxy-location-input" || m.ModalType == "proxy-location-input-lb" ||
-			m.ModalType == "proxy-host-input" || m.ModalType == "proxy-host-input-lb" {
+			m.ModalType == "proxy-host-input" || m.ModalType == "proxy-host-input-lb" ||
+			m.ModalType == "log-span" {
 			// For text input modals, let these keys fall through to default handler
 			if msg.String() == "j" {
 				key := msg.String()
@@ -60,10 +97,22 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.ModalCursor++
 		} else if m.ModalType == "confirm-delete-site" && m.ModalCursor < 1 {
 			m.ModalCursor++
+		} else if m.ModalType == "confirm-reload" && m.ModalCursor < 1 {
+			m.ModalCursor++
+		} else if m.ModalType == "confirm-discard" && m.ModalCursor < 1 {
+			m.ModalCursor++
+		} else if m.ModalType == "confirm-restore" && m.ModalCursor < 1 {
+			m.ModalCursor++
+		} else if m.ModalType == "confirm-write" && m.ModalCursor < 1 {
+			m.ModalCursor++
 		} else if m.ModalType == "site-type" && m.ModalCursor < 3 {
 			m.ModalCursor++
 		} else if m.ModalType == "proxy-type" && m.ModalCursor < 1 {
 			m.ModalCursor++
+		} else if m.ModalType == "log-file" && m.ModalCursor < len(m.LogFiles)-1 {
+			m.ModalCursor++
+		} else if m.ModalType == "site-logs" && m.ModalCursor < 1 {
+			m.ModalCursor++
 		}
 		return m, nil
 
@@ -96,6 +145,90 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.ModalType = ""
 				return m, nil
 			}
+		} else if m.ModalType == "confirm-reload" {
+			m.ShowModal = false
+			m.ModalType = ""
+			if m.ModalCursor == 0 {
+				// Yes selected - graceful reload
+				return m, commands.ReloadNginx
+			}
+			// No selected - keep the change on disk without reloading
+			return m, nil
+		} else if m.ModalType == "confirm-discard" {
+			m.ShowModal = false
+			m.ModalType = ""
+			if m.ModalCursor == 0 {
+				// Yes selected - close the built-in editor without saving
+				cmd := m.closeEditor()
+				return m, cmd
+			}
+			// No selected - keep editing
+			return m, nil
+		} else if m.ModalType == "confirm-restore" {
+			m.ShowModal = false
+			m.ModalType = ""
+			if m.ModalCursor == 0 {
+				// Yes selected - restore the selected backup
+				label := m.SubMenus[m.MainCursor][m.SubCursor]
+				return m, func() tea.Msg {
+					return commands.RestoreBackup(label)
+				}
+			}
+			// No selected - cancel
+			return m, nil
+		} else if m.ModalType == "log-file" {
+			m.ShowModal = false
+			m.ModalType = ""
+			if m.ModalCursor >= len(m.LogFiles) {
+				return m, nil
+			}
+			// View the chosen file in place of the live log
+			path := m.LogFiles[m.ModalCursor].Path
+			m.stopFollow()
+			if m.LogFilesKind == "error" {
+				return m, func() tea.Msg { return commands.ViewErrorLogFile(path) }
+			}
+			return m, func() tea.Msg { return commands.ViewAccessLogFile(path) }
+		} else if m.ModalType == "log-span" {
+			// View the time span of the chosen file once it parses; the modal shows why it doesn't
+			span, err := logs.ParseSpan(m.TextInput, time.Now())
+			if err != nil || m.ModalCursor >= len(m.LogFiles) {
+				return m, nil
+			}
+			m.ShowModal = false
+			m.ModalType = ""
+			m.TextInput = ""
+			path := m.LogFiles[m.ModalCursor].Path
+			m.stopFollow()
+			if m.LogFilesKind == "error" {
+				return m, func() tea.Msg { return commands.ViewErrorLogSpan(path, span) }
+			}
+			return m, func() tea.Msg { return commands.ViewAccessLogSpan(path, span) }
+		} else if m.ModalType == "site-logs" {
+			m.ShowModal = false
+			m.ModalType = ""
+			siteName := m.selectedSite()
+			kind := "access"
+			if m.ModalCursor == 1 {
+				kind = "error"
+			}
+			m.stopFollow()
+			return m, func() tea.Msg { return commands.ViewSiteLog(siteName, kind) }
+		} else if m.ModalType == "confirm-write" {
+			m.ShowModal = false
+			m.ModalType = ""
+			pending := m.PendingWrite
+			m.PendingWrite = nil
+			if m.ModalCursor == 0 && pending != nil {
+				// Yes selected - write the generated file
+				return m, func() tea.Msg {
+					return commands.WriteConfig(*pending)
+				}
+			}
+			// No selected - nothing was written
+			m.DetailOutput = "Cancelled, no file was written."
+			m.DetailScroll = 0
+			return m, nil
 		} else if m.ModalType == "site-type" {
 			if m.ModalCursor == 0 {
 				// Laravel selected - show text input modal for Laravel site name
@@ -218,6 +351,8 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.TextInput = m.TextInput[:len(m.TextInput)-1]
 		} else if m.ModalType == "proxy-input-lb" && len(m.TextInput) > 0 {
 			m.TextInput = m.TextInput[:len(m.TextInput)-1]
+		} else if m.ModalType == "log-span" && len(m.TextInput) > 0 {
+			m.TextInput = m.TextInput[:len(m.TextInput)-1]
 		}
 		return m, nil
 
@@ -255,6 +390,16 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if len(key) == 1 {
 				m.TextInput += key
 			}
+		} else if m.ModalType == "log-file" && msg.String() == "t" {
+			// Ask for a time span of the file under the cursor
+			m.ModalType = "log-span"
+			m.TextInput = ""
+		} else if m.ModalType == "log-span" {
+			// Spans are typed like the since= and until= terms of the filter
+			key := msg.String()
+			if len(key) == 1 {
+				m.TextInput += key
+			}
 		}
 		return m, nil
 	}
@@ -262,7 +407,7 @@ func (m Model) handleModalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) handleSelection() tea.Cmd {
 	// Main menu indices:
-	// 0=Status & Monitoring, 1=Service Control, 2=Sites, 3=Reverse Proxies, 4=Configuration, 5=Logs, 6=Quit
+	// 0=Status & Monitoring, 1=Service Control, 2=Sites, 3=Reverse Proxies, 4=Configuration, 5=Logs, 6=Backups, 7=Quit
 	switch m.MainCursor {
 	case 0: // Status & Monitoring
 		switch m.SubCursor {
@@ -270,6 +415,9 @@ func (m Model) handleSelection() tea.Cmd {
 			return commands.CheckNginxStatus
 		case 1:
 			return commands.TestNginxConfig
+		case 2:
+			// Refresh now; the running tick keeps refreshing afterwards
+			return commands.LoadTraffic(commands.TrafficWindows[m.TrafficWindow])
 		}
 	case 1: // Service Control
 		switch m.SubCursor {
@@ -298,13 +446,29 @@ func (m Model) handleSelection() tea.Cmd {
 		// Auto-loaded, but can also be triggered manually
 		return commands.ViewNginxConfig
 	case 5: // Logs
+		// Reload the file shown, which may be a rotated copy picked with "o",
+		// and the time span picked for it
 		switch m.SubCursor {
 		case 0:
+			if m.ErrorLog != nil {
+				path, span := m.ErrorLog.Path, m.ErrorLog.Span
+				if !span.Empty() {
+					return func() tea.Msg { return commands.ViewErrorLogSpan(path, span) }
+				}
+				return func() tea.Msg { return commands.ViewErrorLogFile(path) }
+			}
 			return commands.ViewErrorLogs
 		case 1:
+			if m.AccessLog != nil {
+				path, span := m.AccessLog.Path, m.AccessLog.Span
+				if !span.Empty() {
+					return func() tea.Msg { return commands.ViewAccessLogSpan(path, span) }
+				}
+				return func() tea.Msg { return commands.ViewAccessLogFile(path) }
+			}
 			return commands.ViewAccessLogs
 		}
-	case 6: // Quit
+	case 7: // Quit
 		return tea.Quit
 	}
 	return nil
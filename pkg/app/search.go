@@ -0,0 +1,122 @@
+package app
+
+import (
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/utils"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// GetSearch returns the details panel search, the index of the current match
+// and whether the query is still being typed
+func (m Model) GetSearch() (query string, regex bool, current int, typing bool) {
+	return m.Search, m.SearchRegex, m.SearchMatch, m.SearchTyping
+}
+
+// startSearch opens the search prompt. Matches are looked for from the
+// first line shown in the details panel.
+func (m *Model) startSearch() {
+	m.ActivePanel = 2
+	m.Search = ""
+	m.SearchTyping = true
+	m.SearchMatch = 0
+	m.SearchOrigin = m.DetailScroll
+}
+
+// clearSearch closes the prompt and removes the highlighting
+func (m *Model) clearSearch() {
+	m.Search = ""
+	m.SearchTyping = false
+	m.SearchMatch = 0
+}
+
+// handleSearchInput handles keys while the search prompt is open
+func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.clearSearch()
+		m.DetailScroll = m.SearchOrigin
+		return m, nil
+
+	case tea.KeyEnter:
+		m.SearchTyping = false
+		return m, nil
+
+	case tea.KeyCtrlR:
+		// Switch between plain text and regular expression
+		m.SearchRegex = !m.SearchRegex
+
+	case tea.KeyBackspace:
+		if m.Search != "" {
+			_, size := utf8.DecodeLastRuneInString(m.Search)
+			m.Search = m.Search[:len(m.Search)-size]
+		}
+
+	case tea.KeySpace:
+		m.Search += " "
+
+	case tea.KeyRunes:
+		m.Search += string(msg.Runes)
+
+	default:
+		return m, nil
+	}
+
+	// Incremental search: go to the first match from where the search started
+	m.SearchMatch = 0
+	matches := m.searchMatches()
+	for i, match := range matches {
+		if match.Line >= m.SearchOrigin {
+			m.SearchMatch = i
+			break
+		}
+	}
+	if len(matches) > 0 {
+		m.showMatch(matches[m.SearchMatch])
+	} else {
+		m.DetailScroll = m.SearchOrigin
+	}
+	return m, nil
+}
+
+// searchMatches returns the matches of the search in the details panel;
+// none while the query is empty or isn't a valid regular expression
+func (m Model) searchMatches() []gui.Match {
+	if m.Search == "" {
+		return nil
+	}
+	re, err := gui.CompileSearch(m.Search, m.SearchRegex)
+	if err != nil {
+		return nil
+	}
+	return gui.FindMatches(m.DetailOutput, re)
+}
+
+// nextMatch moves to the next match, or the previous one when backward is
+// set, wrapping around at either end
+func (m *Model) nextMatch(backward bool) {
+	matches := m.searchMatches()
+	if len(matches) == 0 {
+		return
+	}
+	step := 1
+	if backward {
+		step = -1
+	}
+	m.SearchMatch = (m.SearchMatch + step + len(matches)) % len(matches)
+	m.showMatch(matches[m.SearchMatch])
+}
+
+// showMatch scrolls the details panel to a match, unless it is already
+// visible. In the config view the cursor moves to the line of the match.
+func (m *Model) showMatch(match gui.Match) {
+	visible := m.detailLines()
+	if match.Line < m.DetailScroll || match.Line >= m.DetailScroll+visible {
+		m.DetailScroll = utils.Max(match.Line-visible/3, 0)
+	}
+	if m.configShown() && match.Line >= m.configHeaderLines() {
+		m.ConfigCursor = match.Line - m.configHeaderLines()
+		m.renderConfigView()
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"lazynginx/pkg/logs"
+)
+
+// selectedSite returns the site under the cursor of the Sites submenu,
+// "" on "Add site" and the placeholder rows
+func (m Model) selectedSite() string {
+	if m.MainCursor != 2 || m.SubCursor <= 0 || m.SubCursor >= len(m.SubMenus[2]) {
+		return ""
+	}
+	site := m.SubMenus[2][m.SubCursor]
+	if site == "Loading sites..." || site == "No sites found" {
+		return ""
+	}
+	return site
+}
+
+// siteLog returns "error" or "access" while the selected site's log is
+// shown or followed in the details panel, "" otherwise
+func (m Model) siteLog() string {
+	if m.SiteLog == "" || m.selectedSite() != m.SiteLogSite {
+		return ""
+	}
+	return m.SiteLog
+}
+
+// GetSiteLog returns which log of the selected site is shown, "" for none
+func (m Model) GetSiteLog() string { return m.siteLog() }
+
+// showSiteLog records which site log a parsed log belongs to; logs
+// opened from the Logs menu clear it
+func (m *Model) showSiteLog(kind string, site string, path string) {
+	m.SiteLog, m.SiteLogSite, m.SiteLogPath = "", "", ""
+	if site != "" {
+		m.SiteLog, m.SiteLogSite, m.SiteLogPath = kind, site, path
+	}
+}
+
+// logTitle names a log in the details panel header, e.g.
+// "error log of site blog (/var/log/nginx/blog.error.log)"
+func logTitle(kind string, path string, site string, inherited bool) string {
+	switch {
+	case site == "":
+		return fmt.Sprintf("%s log (%s)", kind, path)
+	case inherited:
+		return fmt.Sprintf("%s log of site %s (%s, the global log: the site sets no %s_log)", kind, site, path, kind)
+	}
+	return fmt.Sprintf("%s log of site %s (%s)", kind, site, path)
+}
+
+// logHeading heads a log in the details panel: the lines read from its end,
+// or the time span picked for it
+func logHeading(title string, lines int, span logs.Span, truncated bool) string {
+	switch {
+	case span.Empty():
+		return fmt.Sprintf("Last %d lines of %s", lines, title)
+	case truncated:
+		return fmt.Sprintf("First %d lines of %s %s", lines, title, span)
+	}
+	return fmt.Sprintf("%d lines of %s %s", lines, title, span)
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"fmt"
+	"lazynginx/pkg/commands"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/textedit"
+	"lazynginx/pkg/utils"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editCmd opens path at line for editing: in the built-in editor when
+// builtin is set or config.yml has "editor: builtin", else in the external
+// editor. line is 0 to open the file at the top.
+func (m Model) editCmd(builtin bool, path string, line int, configType string, siteName string) tea.Cmd {
+	if builtin || config.Get().Editor == "builtin" {
+		return func() tea.Msg { return commands.OpenEdit(path, line) }
+	}
+	return m.openEditorCmd(path, line, configType, siteName)
+}
+
+// GetEditor returns the buffer of the built-in editor, nil when it is closed,
+// with the file it edits and the outcome of the last save
+func (m Model) GetEditor() (buffer *textedit.Buffer, path string, note string) {
+	return m.Editing, m.EditingPath, m.EditingNote
+}
+
+// openBuiltinEditor shows a file in the built-in editor with the cursor at the requested line
+func (m *Model) openBuiltinEditor(msg commands.EditFileMsg) {
+	if msg.Err != nil {
+		m.DetailOutput = "Failed to open file: " + msg.Err.Error() + m.getAdminWarning()
+		m.DetailScroll = 0
+		return
+	}
+	m.Editing = textedit.New(msg.Content)
+	m.EditingPath = msg.Path
+	m.EditingNote = ""
+	m.EditingSaved = false
+	m.ActivePanel = 2
+
+	// Show a few lines of context above the line
+	m.Editing.GoToLine(msg.Line)
+	m.Editing.Top = utils.Max(msg.Line-1-3, 0)
+	m.scrollEditor()
+}
+
+// scrollEditor keeps the cursor of the built-in editor in view
+func (m *Model) scrollEditor() {
+	m.Editing.ScrollTo(m.detailLines()-1, m.WindowWidth/2-4, gui.EditorTabWidth)
+}
+
+// closeEditor closes the built-in editor and reloads the view below it
+// when the file was saved
+func (m *Model) closeEditor() tea.Cmd {
+	saved := m.EditingSaved
+	m.Editing = nil
+	m.EditingPath = ""
+	m.EditingNote = ""
+	m.EditingSaved = false
+	if !saved {
+		return nil
+	}
+
+	switch {
+	case m.diagnosticsShown():
+		return commands.TestNginxConfig
+	case m.CurrentConfigType == "main":
+		return func() tea.Msg { return commands.ViewNginxConfig() }
+	case m.CurrentConfigType == "site":
+		siteName := m.CurrentSiteName
+		return func() tea.Msg { return commands.ViewSiteConfig(siteName) }
+	}
+	return nil
+}
+
+// handleEditorInput handles the keys of the built-in editor, which takes
+// every key while open
+func (m Model) handleEditorInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	buffer := m.Editing
+	page := utils.Max(m.detailLines()-2, 1)
+
+	switch msg.String() {
+	case "ctrl+s":
+		if !buffer.Modified() {
+			m.EditingNote = "No changes to save"
+			return m, nil
+		}
+		m.EditingNote = "Saving and testing with nginx -t..."
+		path, content := m.EditingPath, buffer.String()
+		return m, func() tea.Msg { return commands.SaveEdit(path, content) }
+
+	case "esc", "ctrl+c":
+		// Ask before dropping unsaved changes
+		if buffer.Modified() {
+			m.ShowModal = true
+			m.ModalType = "confirm-discard"
+			m.ModalCursor = 1
+			return m, nil
+		}
+		cmd := m.closeEditor()
+		return m, cmd
+
+	case "ctrl+z":
+		if !buffer.Undo() {
+			m.EditingNote = "Nothing to undo"
+		}
+	case "ctrl+y":
+		if !buffer.Redo() {
+			m.EditingNote = "Nothing to redo"
+		}
+
+	case "up":
+		buffer.MoveUp(1)
+	case "down":
+		buffer.MoveDown(1)
+	case "left":
+		buffer.MoveLeft()
+	case "right":
+		buffer.MoveRight()
+	case "pgup":
+		buffer.MoveUp(page)
+	case "pgdown":
+		buffer.MoveDown(page)
+	case "home", "ctrl+a":
+		buffer.Home()
+	case "end", "ctrl+e":
+		buffer.End()
+	case "ctrl+home":
+		buffer.Start()
+	case "ctrl+end":
+		buffer.Finish()
+
+	case "enter":
+		buffer.Newline()
+	case "backspace":
+		buffer.Backspace()
+	case "delete":
+		buffer.Delete()
+	case "tab":
+		buffer.Indent()
+
+	default:
+		switch {
+		case msg.Paste:
+			buffer.Insert(string(msg.Runes))
+		case (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt:
+			buffer.Type(string(msg.Runes))
+		}
+	}
+
+	m.scrollEditor()
+	return m, nil
+}
+
+// onEditSaved shows how a save from the built-in editor went. A passing
+// nginx -t offers a reload; a failing one moves the cursor to the error.
+func (m *Model) onEditSaved(msg commands.EditSavedMsg) {
+	if m.Editing == nil || msg.Path != m.EditingPath {
+		return
+	}
+	if msg.Err != nil {
+		m.EditingNote = gui.ErrorStyle.Render("Failed to save: " + msg.Err.Error())
+		return
+	}
+
+	// Log paths and site directories may have changed with the edit
+	discovery.Refresh()
+
+	change := msg.Change
+	if change.Test.OK {
+		m.Editing.MarkSaved(msg.Content)
+		m.EditingSaved = true
+		m.EditingNote = gui.StatusStyle.Render("Saved, nginx -t passed")
+		if len(change.Test.Diagnostics) > 0 {
+			m.EditingNote = gui.WarningStyle.Render("Saved, nginx -t passed with warnings")
+		}
+		m.ShowModal = true
+		m.ModalType = "confirm-reload"
+		m.ModalCursor = 0
+		return
+	}
+
+	if change.RollbackErr != nil {
+		m.EditingNote = gui.ErrorStyle.Render("nginx -t failed and the rollback did not complete: " + strings.ReplaceAll(change.RollbackErr.Error(), "\n", " "))
+		return
+	}
+	note := "nginx -t failed, the file was left unchanged"
+	for _, d := range change.Test.Diagnostics {
+		if d.Level != "emerg" && d.Level != "alert" && d.Level != "crit" && d.Level != "error" {
+			continue
+		}
+		note += fmt.Sprintf(": [%s] %s", d.Level, d.Message)
+		if d.File == m.EditingPath && d.Line > 0 {
+			m.Editing.GoToLine(d.Line)
+			m.scrollEditor()
+		} else if d.File != "" {
+			note += fmt.Sprintf(" in %s:%d", d.File, d.Line)
+		}
+		break
+	}
+	m.EditingNote = gui.ErrorStyle.Render(note)
+}
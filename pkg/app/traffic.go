@@ -0,0 +1,41 @@
+package app
+
+import (
+	"lazynginx/pkg/commands"
+	"lazynginx/pkg/gui"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// trafficRefresh is how often the Traffic view reloads the access log
+const trafficRefresh = 5 * time.Second
+
+type trafficTickMsg struct {
+	seq int
+}
+
+func trafficTick(seq int) tea.Cmd {
+	return tea.Tick(trafficRefresh, func(time.Time) tea.Msg {
+		return trafficTickMsg{seq: seq}
+	})
+}
+
+// onTraffic reports whether the Traffic view is selected
+func (m Model) onTraffic() bool {
+	return m.MainCursor == 0 && m.SubCursor == 2
+}
+
+// startTraffic loads the Traffic view and starts refreshing it.
+// Bumping the sequence stops the tick of a previous visit.
+func (m *Model) startTraffic() tea.Cmd {
+	m.TrafficTick++
+	return tea.Batch(commands.LoadTraffic(commands.TrafficWindows[m.TrafficWindow]), trafficTick(m.TrafficTick))
+}
+
+// renderTraffic shows the last statistics in the details panel
+func (m *Model) renderTraffic() {
+	t := m.Traffic
+	// The details panel takes half the window, minus border, padding and scrollbar
+	m.DetailOutput = gui.RenderTraffic(t.Stats, t.Path, t.Format, t.Skipped, m.WindowWidth/2-5)
+}
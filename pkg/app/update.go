@@ -1,9 +1,15 @@
 package app
 
 import (
+	"fmt"
 	"lazynginx/pkg/commands"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/logs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -11,13 +17,20 @@ import (
 )
 
 type EditorFinishedMsg struct {
-	Err        error
-	ConfigType string
-	SiteName   string
+	Err         error
+	ConfigType  string
+	SiteName    string
+	Path        string
+	Transaction *commands.Transaction // snapshot taken before the editor opened
 }
 
-func (m Model) openEditorCmd(path string, configType string, siteName string) tea.Cmd {
-	editor := os.Getenv("EDITOR")
+// openEditorCmd opens path in the editor, at line when it is above 0
+func (m Model) openEditorCmd(path string, line int, configType string, siteName string) tea.Cmd {
+	// The editor setting in config.yml wins over $EDITOR
+	editor := config.Get().Editor
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
 	editorArgs := []string{}
 
 	if editor == "" {
@@ -38,13 +51,18 @@ func (m Model) openEditorCmd(path string, configType string, siteName string) te
 		}
 	}
 
-	cmd := exec.Command(editor, append(editorArgs, path)...)
+	cmd := exec.Command(editor, append(editorArgs, fileArgs(editor, config.Get().EditorLine, path, line)...)...)
+
+	// Snapshot the file so a change nginx -t rejects can be rolled back
+	tx, _ := commands.BeginTransaction("edit "+filepath.Base(path), path)
 
 	return tea.ExecProcess(cmd, func(err error) tea.Msg {
 		return EditorFinishedMsg{
-			Err:        err,
-			ConfigType: configType,
-			SiteName:   siteName,
+			Err:         err,
+			ConfigType:  configType,
+			SiteName:    siteName,
+			Path:        path,
+			Transaction: tx,
 		}
 	})
 }
@@ -52,6 +70,10 @@ func (m Model) openEditorCmd(path string, configType string, siteName string) te
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.MouseMsg:
+		// Clicks would change the view under the built-in editor
+		if m.Editing != nil {
+			return m, nil
+		}
 		switch msg.Type {
 		case tea.MouseLeft:
 			// Calculate which panel was clicked based on x position (horizontal layout)
@@ -85,6 +107,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.MainCursor = newCursor
 						m.SubCursor = 0
 						m.SubScroll = 0
+						m.stopFollow()
 						m.DetailOutput = ""
 						m.DetailScroll = 0
 						// Auto-load status when Status menu selected
@@ -93,7 +116,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 						// Auto-load sites when Sites menu selected
 						if m.MainCursor == 2 {
-							return m, commands.LoadSites(&m)
+							return m, commands.LoadSites
 						}
 						// Auto-load reverse proxies when Reverse Proxies menu selected
 						if m.MainCursor == 3 {
@@ -103,6 +126,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if m.MainCursor == 4 {
 							return m, func() tea.Msg { return commands.ViewNginxConfig() }
 						}
+						// Auto-load backups when Backups menu selected
+						if m.MainCursor == 6 {
+							return m, commands.LoadBackups
+						}
 					}
 				}
 			} else if msg.X < panel2End {
@@ -118,12 +145,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					newSubCursor := m.SubScroll + clickedLine
 					if newSubCursor < len(subItems) && newSubCursor != m.SubCursor {
 						m.SubCursor = newSubCursor
+						m.stopFollow()
 						m.DetailOutput = ""
 						m.DetailScroll = 0
 						// Auto-load status when Check Status selected
 						if m.MainCursor == 0 && m.SubCursor == 0 {
 							return m, commands.CheckNginxStatus
 						}
+						// Auto-load the traffic dashboard when Traffic selected
+						if m.MainCursor == 0 && m.SubCursor == 2 {
+							cmd := m.startTraffic()
+							return m, cmd
+						}
 						// Auto-load logs when in Logs menu
 						if m.MainCursor == 5 {
 							if m.SubCursor == 0 {
@@ -137,6 +170,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							siteName := m.SubMenus[m.MainCursor][m.SubCursor]
 							return m, func() tea.Msg { return commands.ViewSiteConfig(siteName) }
 						}
+						// Auto-load the backup and its diff when in Backups menu
+						if m.MainCursor == 6 {
+							label := m.SubMenus[m.MainCursor][m.SubCursor]
+							return m, func() tea.Msg { return commands.ViewBackup(label) }
+						}
 					}
 				}
 			} else {
@@ -151,6 +189,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.ShowModal {
 			return m.handleModalInput(msg)
 		}
+		// The built-in editor takes every key while open
+		if m.Editing != nil {
+			return m.handleEditorInput(msg)
+		}
+		// Then the search prompt, which takes every key while open
+		if m.SearchTyping {
+			return m.handleSearchInput(msg)
+		}
+		if m.FilterTyping {
+			return m.handleFilterInput(msg)
+		}
+		// The key after z folds the config view: za, zo, zc, zM or zR
+		if m.PendingKey == "z" {
+			m.PendingKey = ""
+			if m.ActivePanel == 2 && m.configShown() {
+				m.fold(msg.String())
+				return m, nil
+			}
+		}
 
 		switch msg.String() {
 		case "ctrl+c", "q":
@@ -180,6 +237,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.MainCursor > 0 {
 					m.MainCursor--
 					m.SubCursor = 0
+					m.stopFollow()
 					m.DetailOutput = ""
 					m.DetailScroll = 0
 					// Adjust scroll to keep cursor visible
@@ -192,7 +250,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					// Auto-load sites when Sites menu selected
 					if m.MainCursor == 2 {
-						return m, commands.LoadSites(&m)
+						return m, commands.LoadSites
 					}
 					// Auto-load reverse proxies when Reverse Proxies menu selected
 					if m.MainCursor == 3 {
@@ -202,10 +260,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.MainCursor == 4 {
 						return m, func() tea.Msg { return commands.ViewNginxConfig() }
 					}
+					// Auto-load backups when Backups menu selected
+					if m.MainCursor == 6 {
+						return m, commands.LoadBackups
+					}
 				}
 			} else if m.ActivePanel == 1 {
 				if m.SubCursor > 0 {
 					m.SubCursor--
+					m.stopFollow()
 					m.DetailOutput = ""
 					m.DetailScroll = 0
 					// Adjust scroll to keep cursor visible
@@ -216,6 +279,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.MainCursor == 0 && m.SubCursor == 0 {
 						return m, commands.CheckNginxStatus
 					}
+					// Auto-load the traffic dashboard when Traffic selected
+					if m.MainCursor == 0 && m.SubCursor == 2 {
+						cmd := m.startTraffic()
+						return m, cmd
+					}
 					// Auto-load logs when in Logs menu
 					if m.MainCursor == 5 {
 						if m.SubCursor == 0 {
@@ -229,7 +297,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						siteName := m.SubMenus[m.MainCursor][m.SubCursor]
 						return m, func() tea.Msg { return commands.ViewSiteConfig(siteName) }
 					}
+					// Auto-load the backup and its diff when in Backups menu
+					if m.MainCursor == 6 {
+						label := m.SubMenus[m.MainCursor][m.SubCursor]
+						return m, func() tea.Msg { return commands.ViewBackup(label) }
+					}
 				}
+			} else if m.ActivePanel == 2 && m.errorLogShown() {
+				// Select the previous error log entry
+				if m.ErrorCursor > 0 {
+					m.ErrorCursor--
+					m.renderErrorLog()
+				}
+			} else if m.ActivePanel == 2 && m.diagnosticsShown() {
+				// Select the previous nginx -t error or warning
+				if m.DiagnosticCursor > 0 {
+					m.DiagnosticCursor--
+					m.renderTest()
+				}
+			} else if m.ActivePanel == 2 && m.configShown() {
+				// Move the cursor of the config view up
+				m.moveConfigCursor(-1)
 			} else if m.ActivePanel == 2 {
 				// Scroll up in details panel
 				if m.DetailScroll > 0 {
@@ -243,6 +331,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.MainCursor < len(m.MainMenu)-1 {
 					m.MainCursor++
 					m.SubCursor = 0
+					m.stopFollow()
 					m.DetailOutput = ""
 					m.DetailScroll = 0
 					// Adjust scroll to keep cursor visible
@@ -260,7 +349,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					// Auto-load sites when Sites menu selected
 					if m.MainCursor == 2 {
-						return m, commands.LoadSites(&m)
+						return m, commands.LoadSites
 					}
 					// Auto-load reverse proxies when Reverse Proxies menu selected
 					if m.MainCursor == 3 {
@@ -270,11 +359,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.MainCursor == 4 {
 						return m, func() tea.Msg { return commands.ViewNginxConfig() }
 					}
+					// Auto-load backups when Backups menu selected
+					if m.MainCursor == 6 {
+						return m, commands.LoadBackups
+					}
 				}
 			} else if m.ActivePanel == 1 {
 				subItems := m.SubMenus[m.MainCursor]
 				if m.SubCursor < len(subItems)-1 {
 					m.SubCursor++
+					m.stopFollow()
 					m.DetailOutput = ""
 					m.DetailScroll = 0
 					// Adjust scroll to keep cursor visible
@@ -290,6 +384,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.MainCursor == 0 && m.SubCursor == 0 {
 						return m, commands.CheckNginxStatus
 					}
+					// Auto-load the traffic dashboard when Traffic selected
+					if m.MainCursor == 0 && m.SubCursor == 2 {
+						cmd := m.startTraffic()
+						return m, cmd
+					}
 					// Auto-load logs when in Logs menu
 					if m.MainCursor == 5 {
 						if m.SubCursor == 0 {
@@ -303,7 +402,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						siteName := m.SubMenus[m.MainCursor][m.SubCursor]
 						return m, func() tea.Msg { return commands.ViewSiteConfig(siteName) }
 					}
+					// Auto-load the backup and its diff when in Backups menu
+					if m.MainCursor == 6 {
+						label := m.SubMenus[m.MainCursor][m.SubCursor]
+						return m, func() tea.Msg { return commands.ViewBackup(label) }
+					}
 				}
+			} else if m.ActivePanel == 2 && m.errorLogShown() {
+				// Select the next error log entry
+				m.ErrorCursor++
+				m.renderErrorLog()
+			} else if m.ActivePanel == 2 && m.diagnosticsShown() {
+				// Select the next nginx -t error or warning
+				m.DiagnosticCursor++
+				m.renderTest()
+			} else if m.ActivePanel == 2 && m.configShown() {
+				// Move the cursor of the config view down
+				m.moveConfigCursor(1)
 			} else if m.ActivePanel == 2 {
 				// Scroll down in details panel
 				m.DetailScroll++
@@ -312,6 +427,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "enter":
 			if m.ActivePanel == 1 {
+				m.stopFollow()
 				// Check if it's "Stop" in Service Control menu
 				if m.MainCursor == 1 && m.SubCursor == 1 {
 					m.ShowModal = true
@@ -333,9 +449,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.ModalCursor = 0
 					return m, nil
 				}
+				// Restoring a backup asks for confirmation first
+				if m.MainCursor == 6 {
+					subItems := m.SubMenus[m.MainCursor]
+					if m.SubCursor < len(subItems) && subItems[m.SubCursor] != "Loading backups..." && subItems[m.SubCursor] != "No backups yet" {
+						m.ShowModal = true
+						m.ModalType = "confirm-restore"
+						m.ModalCursor = 0
+					}
+					return m, nil
+				}
 				// Otherwise execute the selection
 				return m, m.handleSelection()
 			}
+			// Open the config that proxies to the upstream of the selected error
+			if m.ActivePanel == 2 && m.errorLogShown() {
+				if entry := m.selectedError(); entry != nil && entry.Upstream != "" {
+					return m, func() tea.Msg { return commands.ViewUpstreamConfig(entry) }
+				}
+			}
+			// Open the file of the selected nginx -t error at its line
+			if m.ActivePanel == 2 && m.diagnosticsShown() {
+				if d := m.selectedDiagnostic(); d != nil {
+					diagnostic := *d
+					return m, func() tea.Msg { return commands.ViewDiagnostic(diagnostic) }
+				}
+			}
 			return m, nil
 
 		case "d":
@@ -355,10 +494,146 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "e":
+		case " ":
+			// Enable/disable toggle - only works in Sites submenu for actual sites (not "Add site")
+			if m.ActivePanel == 1 && m.MainCursor == 2 && m.SubCursor > 0 {
+				subItems := m.SubMenus[m.MainCursor]
+				if m.SubCursor < len(subItems) {
+					siteName := subItems[m.SubCursor]
+					if siteName != "Loading sites..." && siteName != "No sites found" {
+						return m, func() tea.Msg { return commands.ToggleSite(siteName) }
+					}
+				}
+			}
+			return m, nil
+
+		case "L":
+			// Pick the access or error log of the selected site
+			if m.ActivePanel > 0 && m.selectedSite() != "" {
+				m.ShowModal = true
+				m.ModalType = "site-logs"
+				m.ModalCursor = 0
+			}
+			return m, nil
+
+		case "f":
+			// Follow the selected log, or stop following it
+			if m.ActivePanel > 0 && (m.MainCursor == 5 || m.siteLog() != "") {
+				if m.Follower != nil {
+					m.stopFollow()
+					m.DetailOutput = "Stopped following."
+					return m, nil
+				}
+				if kind := m.siteLog(); kind != "" {
+					name, path := kind+" log of site "+m.SiteLogSite, m.SiteLogPath
+					return m, func() tea.Msg { return commands.FollowLog(name, path, config.Get().TailLines) }
+				}
+				if m.SubCursor == 0 {
+					return m, commands.FollowErrorLog
+				}
+				return m, commands.FollowAccessLog
+			}
+			return m, nil
+
+		case "w":
+			// Cycle the time window of the Traffic view
+			if m.ActivePanel > 0 && m.onTraffic() {
+				m.TrafficWindow = (m.TrafficWindow + 1) % len(commands.TrafficWindows)
+				return m, commands.LoadTraffic(commands.TrafficWindows[m.TrafficWindow])
+			}
+			return m, nil
+
+		case "s":
+			// Cycle the lowest severity shown in the error log
+			if m.ActivePanel > 0 && m.errorLogShown() {
+				m.ErrorLevel = (m.ErrorLevel + 1) % len(errorLevels)
+				m.ErrorCursor = len(m.errorItems()) - 1
+				m.renderErrorLog()
+			}
+			return m, nil
+
+		case "g":
+			// Group repeated error log messages, or list every line
+			if m.ActivePanel > 0 && m.errorLogShown() {
+				m.ErrorsGrouped = !m.ErrorsGrouped
+				m.ErrorCursor = len(m.errorItems()) - 1
+				m.renderErrorLog()
+			}
+			return m, nil
+
+		case "F":
+			// Filter the access log records
+			if m.ActivePanel > 0 && m.accessLogShown() {
+				m.startFilter()
+			}
+			return m, nil
+
+		case "o":
+			// Pick a rotated copy of the selected log
+			if m.ActivePanel > 0 && m.MainCursor == 5 {
+				kind := "error"
+				if m.SubCursor == 1 {
+					kind = "access"
+				}
+				return m, func() tea.Msg { return commands.ListLogFiles(kind) }
+			}
+			return m, nil
+
+		case "/":
+			// Search the details panel
+			m.startSearch()
+			return m, nil
+
+		case "n", "N":
+			// Jump to the next/previous search match
+			if m.ActivePanel == 2 && m.Search != "" {
+				m.nextMatch(msg.String() == "N")
+			}
+			return m, nil
+
+		case "esc":
+			m.clearSearch()
+			return m, nil
+
+		case "z":
+			// Start a fold command on the config view
+			if m.ActivePanel == 2 && m.configShown() {
+				m.PendingKey = "z"
+			}
+			return m, nil
+
+		case "#":
+			// Show or hide the line numbers of the config view
+			if m.ActivePanel == 2 && m.configShown() {
+				m.LineNumbers = !m.LineNumbers
+				m.renderConfigView()
+			}
+			return m, nil
+
+		case "p":
+			// Pause/resume the followed log; lines keep being buffered while paused
+			if m.Follower != nil {
+				m.FollowPaused = !m.FollowPaused
+				m.renderFollow()
+			}
+			return m, nil
+
+		case "e", "E":
+			// [E] edits in the built-in editor, [e] in the configured one
+			builtin := msg.String() == "E"
+
 			// Edit from details panel (panel 2)
 			if m.ActivePanel == 2 && m.CurrentConfigPath != "" {
-				return m, m.openEditorCmd(m.CurrentConfigPath, m.CurrentConfigType, m.CurrentSiteName)
+				at := m.editPosition()
+				return m, m.editCmd(builtin, at.File, at.Line, m.CurrentConfigType, m.CurrentSiteName)
+			}
+
+			// Edit the file of the selected nginx -t error at its line
+			if m.ActivePanel == 2 && m.diagnosticsShown() {
+				if d := m.selectedDiagnostic(); d != nil && d.File != "" {
+					return m, m.editCmd(builtin, d.File, d.Line, "", "")
+				}
+				return m, nil
 			}
 
 			// Edit from main menu (panel 0) - for Configuration menu
@@ -369,7 +644,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.DetailScroll = 0
 					return m, nil
 				}
-				return m, m.openEditorCmd(path, "main", "")
+				return m, m.editCmd(builtin, path, 0, "main", "")
 			}
 
 			// Edit from submenu (panel 1)
@@ -381,7 +656,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.DetailScroll = 0
 						return m, nil
 					}
-					return m, m.openEditorCmd(path, "main", "")
+					return m, m.editCmd(builtin, path, 0, "main", "")
 				}
 
 				if m.MainCursor == 2 && m.SubCursor > 0 {
@@ -395,7 +670,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 								m.DetailScroll = 0
 								return m, nil
 							}
-							return m, m.openEditorCmd(path, "site", siteName)
+							return m, m.editCmd(builtin, path, 0, "site", siteName)
 						}
 					}
 				}
@@ -403,50 +678,235 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+	case commands.SitesMsg:
+		m.SiteStates = msg.States
+		if len(msg.Sites) == 0 {
+			// Keep the "Add site" option
+			m.SubMenus[2] = []string{"Add site", "No sites found"}
+			return m.Update(commands.StatusMsg{Status: "No sites configured"})
+		}
+		m.SubMenus[2] = append([]string{"Add site"}, msg.Sites...)
+		return m.Update(commands.StatusMsg{Status: fmt.Sprintf("Found %d sites", len(msg.Sites))})
+
+	case commands.BackupsMsg:
+		if msg.Err != nil {
+			m.SubMenus[6] = []string{"No backups yet"}
+			return m.Update(commands.OutputMsg{Output: fmt.Sprintf("Could not read backups from %s: %s", msg.Dir, msg.Err.Error())})
+		}
+		if len(msg.Labels) == 0 {
+			m.SubMenus[6] = []string{"No backups yet"}
+			return m.Update(commands.StatusMsg{Status: fmt.Sprintf("No backups yet.\n\nlazynginx saves a backup in %s before it adds, deletes, enables, disables or edits a configuration file.", msg.Dir)})
+		}
+		m.SubMenus[6] = msg.Labels
+		return m.Update(commands.StatusMsg{Status: fmt.Sprintf("Found %d backups in %s", len(msg.Labels), msg.Dir)})
+
 	case commands.StatusMsg:
 		m.Status = msg.Status
 		m.DetailOutput = msg.Status + m.getAdminWarning() // Also display in details panel with warning
+		m.showSiteLog("", "", "")
 		m.CurrentConfigPath = ""
 		m.CurrentConfigType = ""
 		m.CurrentSiteName = ""
 		m.DetailScroll = 0 // Reset scroll on new content
 		return m, nil
 
+	case commands.NginxStatusMsg:
+		m.Status = msg.Status.Description
+		m.showOutput(gui.RenderStatus(msg.Status))
+		return m, nil
+
+	case commands.TestMsg:
+		m.showOutput("")
+		m.Test = &msg.Result
+		m.DiagnosticCursor = 0
+		m.renderTest()
+		return m, nil
+
+	case commands.ServiceMsg:
+		// The reload offered after a save of the built-in editor is reported under it
+		if m.Editing != nil {
+			summary, _, _ := strings.Cut(gui.RenderService(msg.Result), "\n")
+			if msg.Result.OK {
+				m.EditingNote = gui.StatusStyle.Render(summary)
+			} else {
+				m.EditingNote = gui.ErrorStyle.Render(summary)
+			}
+			return m, nil
+		}
+		m.showOutput(gui.RenderService(msg.Result))
+		return m, nil
+
+	case commands.EditFileMsg:
+		m.openBuiltinEditor(msg)
+		return m, nil
+
+	case commands.EditSavedMsg:
+		m.onEditSaved(msg)
+		return m, nil
+
 	case commands.ConfigViewMsg:
-		m.DetailOutput = msg.Output + m.getAdminWarning()
+		m.showConfigView(msg)
+		m.showSiteLog("", "", "")
 		m.CurrentConfigPath = msg.Path
 		m.CurrentConfigType = msg.Type
 		m.CurrentSiteName = msg.SiteName
-		m.DetailScroll = 0
+		m.DetailScroll = msg.ScrollTo
+		return m, nil
+
+	case commands.LogFilesMsg:
+		m.LogFiles = msg.Files
+		m.LogFilesKind = msg.Kind
+		m.ShowModal = true
+		m.ModalType = "log-file"
+		// Start on the file shown now
+		m.ModalCursor = 0
+		shown := ""
+		if msg.Kind == "error" && m.ErrorLog != nil {
+			shown = m.ErrorLog.Path
+		} else if msg.Kind == "access" && m.AccessLog != nil {
+			shown = m.AccessLog.Path
+		}
+		for i, f := range msg.Files {
+			if f.Path == shown {
+				m.ModalCursor = i
+			}
+		}
+		return m, nil
+
+	case commands.AccessLogMsg:
+		m.AccessLog = &msg
+		m.showSiteLog("access", msg.Site, msg.Path)
+		m.CurrentConfigPath = ""
+		m.CurrentConfigType = ""
+		m.CurrentSiteName = ""
+		m.renderAccessLog()
+		if !msg.Span.Empty() {
+			return m, nil
+		}
+		// The filter searches more of the log than the lines shown
+		path := msg.Path
+		return m, func() tea.Msg { return commands.LoadAccessRecords(path) }
+
+	case commands.AccessRecordsMsg:
+		// Records of a log that is no longer shown are dropped
+		if m.AccessLog == nil || msg.Path != m.AccessLog.Path {
+			return m, nil
+		}
+		m.AccessRecords = &msg
+		if m.accessLogShown() && m.LogFilter != "" {
+			m.renderAccessLog()
+		}
+		return m, nil
+
+	case commands.ErrorLogMsg:
+		m.ErrorLog = &msg
+		m.showSiteLog("error", msg.Site, msg.Path)
+		m.CurrentConfigPath = ""
+		m.CurrentConfigType = ""
+		m.CurrentSiteName = ""
+		// Start at the newest entry, like the end of the log
+		m.ErrorCursor = len(m.errorItems()) - 1
+		m.renderErrorLog()
 		return m, nil
 
 	case commands.OutputMsg:
-		m.DetailOutput = msg.Output + m.getAdminWarning()
+		m.showOutput(msg.Output)
+		return m, nil
+
+	case commands.FollowStartedMsg:
+		// The user moved on while the follow was starting
+		if !m.followsSelection(msg.Follower.Path()) {
+			msg.Follower.Stop()
+			// Next sees the stop and closes the file; its message is dropped
+			return m, commands.WaitForLogLines(msg.Follower)
+		}
+		m.stopFollow()
+		m.ErrorLog = nil
+		m.AccessLog = nil
+		m.Follower = msg.Follower
+		m.FollowTitle = msg.Title
+		m.FollowBuffer = logs.NewRing(commands.FollowBufferLines)
+		m.FollowBuffer.Push(msg.Lines...)
+		m.FollowPaused = false
 		m.CurrentConfigPath = ""
 		m.CurrentConfigType = ""
 		m.CurrentSiteName = ""
-		m.DetailScroll = 0 // Reset scroll on new content
+		m.renderFollow()
+		return m, commands.WaitForLogLines(msg.Follower)
 
-		// Check if we need to reload sites after add/delete operations
-		if m.MainCursor == 2 && (strings.Contains(msg.Output, "Site '") && (strings.Contains(msg.Output, "created successfully") || strings.Contains(msg.Output, "deleted successfully"))) {
-			// Reload sites list after successful add or delete
-			return m, commands.LoadSites(&m)
+	case commands.LogLinesMsg:
+		// Lines from a follow that was stopped in the meantime
+		if msg.Follower != m.Follower {
+			return m, nil
 		}
+		if msg.Err != nil {
+			m.stopFollow()
+			if msg.Err != logs.ErrStopped {
+				m.DetailOutput += "\n\nStopped following: " + msg.Err.Error()
+			}
+			return m, nil
+		}
+		m.FollowBuffer.Push(msg.Lines...)
+		if !m.FollowPaused {
+			m.renderFollow()
+		}
+		return m, commands.WaitForLogLines(msg.Follower)
+
+	case commands.PreviewMsg:
+		// Show the generated file before anything is written
+		m.PendingWrite = &msg.Write
+		m.Preview = msg.Preview
+		m.ModalScroll = 0
+		m.ShowModal = true
+		m.ModalType = "confirm-write"
+		// Default to "No" when an existing file would be overwritten
+		m.ModalCursor = 0
+		if msg.Write.Exists {
+			m.ModalCursor = 1
+		}
+		return m, nil
 
-		// Check if we need to reload reverse proxies after add operation
-		if m.MainCursor == 3 && strings.Contains(msg.Output, "Reverse proxy") && strings.Contains(msg.Output, "created successfully") {
-			// Reload reverse proxies list after successful add
-			return m, commands.LoadReverseProxies(&m)
+	case commands.ConfigChangedMsg:
+		m.showOutput(gui.RenderChange(msg.Change))
+
+		// Offer a graceful reload once nginx -t accepted the new state
+		if msg.Change.Test.OK {
+			m.ShowModal = true
+			m.ModalType = "confirm-reload"
+			m.ModalCursor = 0
 		}
 
+		// Refresh the lists the change may affect
+		if m.MainCursor == 2 {
+			return m, commands.LoadSites
+		}
+		if m.MainCursor == 3 {
+			return m, commands.LoadReverseProxies(&m)
+		}
+		if m.MainCursor == 6 {
+			return m, commands.LoadBackups
+		}
 		return m, nil
 
 	case EditorFinishedMsg:
+		// Test the edited file, rolling it back if nginx rejects it. An
+		// editor that exits with an error (vim's :cq) may still have written it.
+		if msg.Transaction != nil && msg.Transaction.Changed() {
+			// Log paths and site directories may have changed with the edit
+			discovery.Refresh()
+			tx, path := msg.Transaction, msg.Path
+			return m, func() tea.Msg { return tx.ValidateEdit(path) }
+		}
+		// Nothing was saved, so the backup taken before opening isn't needed
+		if msg.Transaction != nil {
+			msg.Transaction.DiscardBackup()
+		}
 		if msg.Err != nil {
 			m.DetailOutput = "Failed to open editor: " + msg.Err.Error() + m.getAdminWarning()
 			m.DetailScroll = 0
 			return m, nil
 		}
+
 		// Reload the config
 		if msg.ConfigType == "main" {
 			return m, func() tea.Msg { return commands.ViewNginxConfig() }
@@ -455,9 +915,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case commands.TrafficMsg:
+		// Drop a refresh that arrives after leaving the Traffic view
+		if !m.onTraffic() {
+			return m, nil
+		}
+		m.Traffic = &msg
+		m.CurrentConfigPath = ""
+		m.CurrentConfigType = ""
+		m.CurrentSiteName = ""
+		m.renderTraffic()
+		return m, nil
+
+	case trafficTickMsg:
+		// Refresh while the Traffic view is shown; ticks of an older view stop here
+		if msg.seq != m.TrafficTick || !m.onTraffic() {
+			return m, nil
+		}
+		return m, tea.Batch(commands.LoadTraffic(commands.TrafficWindows[m.TrafficWindow]), trafficTick(msg.seq))
+
 	case tea.WindowSizeMsg:
 		m.WindowWidth = msg.Width
 		m.WindowHeight = msg.Height
+		if m.onTraffic() && m.Traffic != nil {
+			m.renderTraffic()
+		}
+		if m.errorLogShown() {
+			m.renderErrorLog()
+		}
+		if m.diagnosticsShown() {
+			m.renderTest()
+		}
+		if m.Editing != nil {
+			m.scrollEditor()
+		}
 		return m, nil
 	}
 
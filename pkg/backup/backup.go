@@ -0,0 +1,247 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"lazynginx/pkg/config"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// idFormat names snapshot directories so they sort chronologically
+const idFormat = "2006-01-02_15-04-05"
+
+// maxSnapshots is how many snapshots the store keeps; Create removes the
+// oldest beyond it
+const maxSnapshots = 100
+
+// File is one backed up path
+type File struct {
+	Path     string `json:"path"`
+	Symlink  string `json:"symlink,omitempty"` // link target if the path was a symlink
+	StoredAs string `json:"stored_as,omitempty"`
+	Mode     uint32 `json:"mode"`
+}
+
+// Snapshot is a set of files saved before lazynginx changed them
+type Snapshot struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason"`
+	Files     []File    `json:"files"`
+}
+
+// Dir returns the backup store inside the lazynginx configuration directory
+func Dir() string {
+	return filepath.Join(config.Dir(), "backups")
+}
+
+// Label is the text shown in the Backups submenu; it starts with the ID
+func (s Snapshot) Label() string {
+	return s.ID + "  " + s.Reason
+}
+
+// IDFromLabel returns the snapshot ID of a submenu label
+func IDFromLabel(label string) string {
+	id, _, _ := strings.Cut(label, "  ")
+	return id
+}
+
+// Create saves the given paths into a new snapshot. Paths that don't exist
+// are skipped; if none exists no snapshot is created and nil is returned.
+func Create(reason string, paths ...string) (*Snapshot, error) {
+	now := time.Now()
+	snapshot := &Snapshot{Timestamp: now, Reason: reason}
+
+	type saved struct {
+		file    File
+		content []byte
+	}
+	var files []saved
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		file := File{Path: path, Mode: uint32(info.Mode().Perm())}
+		var content []byte
+		if info.Mode()&os.ModeSymlink != 0 {
+			file.Symlink, err = os.Readlink(path)
+		} else {
+			content, err = os.ReadFile(path)
+			file.StoredAs = strconv.Itoa(len(files))
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, saved{file, content})
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	// Pick a free directory name; two snapshots can happen in the same second
+	base := now.Format(idFormat)
+	snapshot.ID = base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(Dir(), snapshot.ID)); os.IsNotExist(err) {
+			break
+		}
+		snapshot.ID = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	dir := filepath.Join(Dir(), snapshot.ID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create backup directory: %v", err)
+	}
+
+	for _, f := range files {
+		if f.file.StoredAs != "" {
+			if err := os.WriteFile(filepath.Join(dir, f.file.StoredAs), f.content, 0600); err != nil {
+				return nil, fmt.Errorf("could not save backup of %s: %v", f.file.Path, err)
+			}
+		}
+		snapshot.Files = append(snapshot.Files, f.file)
+	}
+
+	meta, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "snapshot.json"), meta, 0600); err != nil {
+		return nil, fmt.Errorf("could not save backup metadata: %v", err)
+	}
+
+	// The new snapshot is saved either way, so a failed prune is left for the next one
+	prune()
+	return snapshot, nil
+}
+
+// prune removes the oldest snapshots beyond maxSnapshots
+func prune() error {
+	snapshots, err := List()
+	if err != nil {
+		return err
+	}
+	for i := maxSnapshots; i < len(snapshots); i++ {
+		if err := Remove(snapshots[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns every snapshot, newest first
+func List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		snapshot, err := Get(entry.Name())
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.After(snapshots[j].Timestamp)
+	})
+	return snapshots, nil
+}
+
+// Get loads a snapshot by ID
+func Get(id string) (*Snapshot, error) {
+	meta, err := os.ReadFile(filepath.Join(Dir(), id, "snapshot.json"))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(meta, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid backup %s: %v", id, err)
+	}
+	return snapshot, nil
+}
+
+// Remove deletes a snapshot
+func Remove(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid backup id %q", id)
+	}
+	return os.RemoveAll(filepath.Join(Dir(), id))
+}
+
+// Content returns the saved content of a backed up file
+func (s Snapshot) Content(file File) (string, error) {
+	if file.Symlink != "" {
+		return "", fmt.Errorf("%s was a symlink to %s", file.Path, file.Symlink)
+	}
+	content, err := os.ReadFile(filepath.Join(Dir(), s.ID, file.StoredAs))
+	return string(content), err
+}
+
+// Paths returns the original paths of the backed up files
+func (s Snapshot) Paths() []string {
+	var paths []string
+	for _, file := range s.Files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// Restore writes every file of the snapshot back to its original path
+func (s Snapshot) Restore() error {
+	for _, file := range s.Files {
+		if file.Symlink != "" {
+			if _, err := os.Lstat(file.Path); err == nil {
+				if err := os.Remove(file.Path); err != nil {
+					return err
+				}
+			}
+			if err := os.Symlink(file.Symlink, file.Path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		content, err := s.Content(file)
+		if err != nil {
+			return err
+		}
+		// A symlink in the way would otherwise be followed
+		if info, err := os.Lstat(file.Path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(file.Path); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.Path, []byte(content), os.FileMode(file.Mode)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTempStore moves the backup store to a temp dir and returns a second
+// temp dir for the files being backed up
+func useTempStore(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return t.TempDir()
+}
+
+func TestCreateRestore(t *testing.T) {
+	dir := useTempStore(t)
+	site := filepath.Join(dir, "site.conf")
+	link := filepath.Join(dir, "enabled.conf")
+	missing := filepath.Join(dir, "missing.conf")
+
+	if err := os.WriteFile(site, []byte("server {}\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(site, link); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := Create("edit site.conf", site, link, missing, "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := snapshot.Paths(); len(got) != 2 || got[0] != site || got[1] != link {
+		t.Fatalf("Paths() = %v, want the two existing paths", got)
+	}
+
+	// Change everything, then put it back
+	if err := os.WriteFile(site, []byte("server { listen 81; }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(link)
+	if err := os.WriteFile(link, []byte("not a link\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := Get(snapshot.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Reason != "edit site.conf" || len(stored.Files) != 2 {
+		t.Fatalf("Get = %+v", stored)
+	}
+	if err := stored.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if content, _ := os.ReadFile(site); string(content) != "server {}\n" {
+		t.Errorf("site.conf = %q after restore", content)
+	}
+	if info, _ := os.Stat(site); info.Mode().Perm() != 0o640 {
+		t.Errorf("site.conf mode = %v, want 0640", info.Mode().Perm())
+	}
+	if target, err := os.Readlink(link); err != nil || target != site {
+		t.Errorf("enabled.conf = %q, %v after restore, want a link to %s", target, err, site)
+	}
+	if _, err := os.Lstat(missing); !os.IsNotExist(err) {
+		t.Errorf("missing.conf was created by the restore")
+	}
+}
+
+func TestRestoreDeletedFile(t *testing.T) {
+	dir := useTempStore(t)
+	site := filepath.Join(dir, "sites", "site.conf")
+	if err := os.MkdirAll(filepath.Dir(site), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(site, []byte("server {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := Create("delete site", site)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Dir(site)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := snapshot.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if content, _ := os.ReadFile(site); string(content) != "server {}\n" {
+		t.Errorf("site.conf = %q after restore", content)
+	}
+}
+
+func TestCreateNothingToSave(t *testing.T) {
+	dir := useTempStore(t)
+
+	snapshot, err := Create("add site", filepath.Join(dir, "new.conf"))
+	if err != nil || snapshot != nil {
+		t.Fatalf("Create = %v, %v, want no snapshot", snapshot, err)
+	}
+	if snapshots, _ := List(); len(snapshots) != 0 {
+		t.Errorf("List = %v, want none", snapshots)
+	}
+}
+
+func TestCreatePrunes(t *testing.T) {
+	dir := useTempStore(t)
+	site := filepath.Join(dir, "site.conf")
+	if err := os.WriteFile(site, []byte("server {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, last *Snapshot
+	for i := 0; i < maxSnapshots+2; i++ {
+		snapshot, err := Create("edit", site)
+		if err != nil {
+			t.Fatalf("Create %d: %v", i, err)
+		}
+		if first == nil {
+			first = snapshot
+		}
+		last = snapshot
+	}
+
+	snapshots, err := List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(snapshots) != maxSnapshots {
+		t.Fatalf("List has %d snapshots, want %d", len(snapshots), maxSnapshots)
+	}
+	if snapshots[0].ID != last.ID {
+		t.Errorf("newest snapshot = %s, want %s", snapshots[0].ID, last.ID)
+	}
+	// IDs of removed snapshots can be taken again within the same second
+	for _, snapshot := range snapshots {
+		if snapshot.Timestamp.Equal(first.Timestamp) {
+			t.Errorf("oldest snapshot %s was kept", first.ID)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	useTempStore(t)
+	for _, id := range []string{"", "../config", `a\b`} {
+		if err := Remove(id); err == nil {
+			t.Errorf("Remove(%q) succeeded, want an error", id)
+		}
+	}
+}
+
+func TestIDFromLabel(t *testing.T) {
+	s := Snapshot{ID: "2026-10-17_10-00-00-2", Reason: "edit  site.conf"}
+	if got := IDFromLabel(s.Label()); got != s.ID {
+		t.Errorf("IDFromLabel(%q) = %q, want %q", s.Label(), got, s.ID)
+	}
+}
@@ -0,0 +1,536 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lazynginx/pkg/commands"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/gui"
+	"lazynginx/pkg/logs"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Usage lists the subcommands, printed by lazynginx -h
+const Usage = `Commands (without one the interactive UI starts):
+  status                         show whether nginx is running (exit 3 when it isn't)
+  test                           test the configuration with nginx -t
+  reload                         gracefully reload nginx
+  sites list                     list sites and whether they are enabled
+  sites enable <name>            enable a site and test the configuration
+  sites disable <name>           disable a site and test the configuration
+  sites add --type <type> <name> create a site: laravel, static, php or custom
+  proxies list                   list reverse proxies
+  logs tail [error|access]       print the end of a log (-n lines, -f follow, --site name)
+  logs stats                     summarize the access and error logs (--window 1h)
+
+Every command takes --output json for structured output.
+`
+
+// siteTypes maps the --type values of "sites add" to the site types of the UI
+var siteTypes = map[string]string{
+	"laravel": "Laravel",
+	"static":  "Static",
+	"php":     "VanillaPHP",
+	"custom":  "Custom",
+}
+
+// errUsage reports a wrong invocation; it exits with 2 like flag errors
+type errUsage string
+
+func (e errUsage) Error() string { return string(e) }
+
+// exitError carries the exit code of a command that ran but failed.
+// The output explaining why has already been printed.
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+// cli runs one command and prints its result as text or JSON
+type cli struct {
+	json   bool
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run runs the subcommand in args and returns the exit code. output is
+// "text" or "json"; an --output flag among args overrides it.
+func Run(args []string, output string, stdout io.Writer, stderr io.Writer) int {
+	args, output, err := outputFlag(args, output)
+	c := &cli{json: output == "json", stdout: stdout, stderr: stderr}
+	if err == nil {
+		err = c.run(args)
+	}
+
+	switch e := err.(type) {
+	case nil:
+		return 0
+	case exitError:
+		return int(e)
+	case errUsage:
+		fmt.Fprintf(stderr, "Error: %s\n\n%s", e, Usage)
+		return 2
+	}
+	if c.json {
+		c.printJSON(errorJSON{Error: err.Error()})
+	} else {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+	}
+	return 1
+}
+
+// outputFlag takes --output out of the arguments, wherever it is, so it
+// works before and after the command
+func outputFlag(args []string, output string) ([]string, string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--output" && name != "-output" && name != "-o" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, "", errUsage("--output needs a value: text or json")
+			}
+			i++
+			value = args[i]
+		}
+		output = value
+	}
+	if output != "text" && output != "json" {
+		return nil, "", errUsage(fmt.Sprintf("unknown output %q (use text or json)", output))
+	}
+	return rest, output, nil
+}
+
+func (c *cli) run(args []string) error {
+	if len(args) == 0 {
+		return errUsage("no command given")
+	}
+	command := strings.Join(args[:min(len(args), 2)], " ")
+	switch {
+	case args[0] == "status":
+		return c.status()
+	case args[0] == "test":
+		return c.test()
+	case args[0] == "reload":
+		return c.reload()
+	case command == "sites list":
+		return c.sitesList()
+	case command == "sites enable", command == "sites disable":
+		return c.sitesToggle(args[1] == "enable", args[2:])
+	case command == "sites add":
+		return c.sitesAdd(args[2:])
+	case command == "proxies list":
+		return c.proxiesList()
+	case command == "logs tail":
+		return c.logsTail(args[2:])
+	case command == "logs stats":
+		return c.logsStats(args[2:])
+	}
+	return errUsage(fmt.Sprintf("unknown command %q", command))
+}
+
+func (c *cli) status() error {
+	status := commands.NginxStatus()
+	if c.json {
+		c.printJSON(newStatusJSON(status))
+	} else {
+		fmt.Fprintln(c.stdout, status.Description)
+		if status.PID != 0 {
+			fmt.Fprintf(c.stdout, "PID:    %d\n", status.PID)
+		}
+		if status.Uptime != 0 {
+			fmt.Fprintf(c.stdout, "Uptime: %s\n", status.Uptime)
+		}
+	}
+	if !status.Running {
+		// LSB status code for "program is not running"
+		return exitError(3)
+	}
+	return nil
+}
+
+func (c *cli) test() error {
+	result := commands.TestConfig()
+	switch {
+	case c.json:
+		c.printJSON(newTestJSON(result))
+	case result.OK:
+		printOutput(c.stdout, result.Output)
+	default:
+		printOutput(c.stderr, result.Output)
+	}
+	if !result.OK {
+		return exitError(1)
+	}
+	return nil
+}
+
+func (c *cli) reload() error {
+	result := commands.Reload()
+	switch {
+	case c.json:
+		c.printJSON(newServiceJSON(result))
+	case result.OK:
+		printOutput(c.stdout, strings.TrimRight(gui.RenderService(result), "\n"))
+	default:
+		printOutput(c.stderr, gui.RenderService(result))
+	}
+	if !result.OK {
+		return exitError(1)
+	}
+	return nil
+}
+
+func (c *cli) sitesList() error {
+	var sites []commands.Site
+	for _, name := range commands.ListSites() {
+		sites = append(sites, commands.SiteDetails(name))
+	}
+	if c.json {
+		list := []siteJSON{}
+		for _, site := range sites {
+			list = append(list, newSiteJSON(site))
+		}
+		c.printJSON(list)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	for _, site := range sites {
+		state := "disabled"
+		if site.Enabled {
+			state = "enabled"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", site.Name, state, site.Path)
+	}
+	return w.Flush()
+}
+
+func (c *cli) sitesToggle(enable bool, args []string) error {
+	action := "disable"
+	if enable {
+		action = "enable"
+	}
+	names, err := parseArgs(flag.NewFlagSet("sites "+action, flag.ContinueOnError), args, c.stderr)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errUsage("sites " + action + " takes one site name")
+	}
+	if !slices.Contains(commands.ListSites(), names[0]) {
+		return fmt.Errorf("no site named %q", names[0])
+	}
+	if commands.SiteEnabled(names[0]) == enable {
+		message := fmt.Sprintf("Site '%s' is already %sd", names[0], action)
+		if c.json {
+			c.printJSON(resultJSON{OK: true, Output: message})
+		} else {
+			fmt.Fprintln(c.stdout, message)
+		}
+		return nil
+	}
+	return c.printChange(commands.ToggleSite(names[0]))
+}
+
+func (c *cli) sitesAdd(args []string) error {
+	flags := flag.NewFlagSet("sites add", flag.ContinueOnError)
+	siteType := flags.String("type", "custom", "site type: laravel, static, php or custom")
+	force := flags.Bool("force", false, "overwrite an existing file with the same name")
+	names, err := parseArgs(flags, args, c.stderr)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errUsage("sites add takes one site name")
+	}
+	kind, ok := siteTypes[strings.ToLower(*siteType)]
+	if !ok {
+		return errUsage(fmt.Sprintf("unknown site type %q (use laravel, static, php or custom)", *siteType))
+	}
+
+	// The UI shows this as a preview to accept; here it is accepted unless it would overwrite a file
+	msg := commands.AddSite(kind, names[0])
+	preview, ok := msg.(commands.PreviewMsg)
+	if !ok {
+		return c.printChange(msg)
+	}
+	if preview.Write.Exists && !*force {
+		message := fmt.Sprintf("%s already exists, use --force to overwrite it:\n\n%s", preview.Write.Path, preview.Preview)
+		if c.json {
+			c.printJSON(resultJSON{OK: false, Output: message})
+		} else {
+			fmt.Fprintln(c.stderr, message)
+		}
+		return exitError(1)
+	}
+	return c.printChange(commands.WriteConfig(preview.Write))
+}
+
+func (c *cli) proxiesList() error {
+	proxies := commands.ReverseProxies()
+	if c.json {
+		list := []proxyJSON{}
+		for _, proxy := range proxies {
+			list = append(list, newProxyJSON(proxy))
+		}
+		c.printJSON(list)
+		return nil
+	}
+	for _, proxy := range proxies {
+		fmt.Fprintln(c.stdout, proxy.String())
+	}
+	return nil
+}
+
+// parseArgs parses flags anywhere among the arguments, so both
+// "sites add --type laravel blog" and "sites add blog --type laravel" work,
+// and returns the other arguments
+func parseArgs(flags *flag.FlagSet, args []string, stderr io.Writer) ([]string, error) {
+	flags.SetOutput(stderr)
+	var rest []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage(err.Error())
+		}
+		if flags.NArg() == 0 {
+			return rest, nil
+		}
+		rest = append(rest, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// printOutput prints command output, ending it with a newline
+func printOutput(w io.Writer, output string) {
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	fmt.Fprint(w, output)
+}
+
+// printChange prints the result of a command that changes config files.
+// It fails when nginx -t rejected the change, which was then rolled back.
+func (c *cli) printChange(msg tea.Msg) error {
+	var change commands.Change
+	switch msg := msg.(type) {
+	case commands.ConfigChangedMsg:
+		change = msg.Change
+	case commands.OutputMsg:
+		// Nothing was changed
+		return fmt.Errorf("%s", msg.Output)
+	default:
+		return fmt.Errorf("unexpected result %T", msg)
+	}
+
+	output := strings.TrimRight(gui.RenderChange(change), "\n") + "\n"
+	switch {
+	case c.json:
+		c.printJSON(newChangeJSON(change))
+	case change.Test.OK:
+		fmt.Fprint(c.stdout, output)
+		fmt.Fprintln(c.stdout, "\nRun 'lazynginx reload' to apply the change.")
+	default:
+		fmt.Fprint(c.stderr, output)
+	}
+	if !change.Test.OK {
+		return exitError(1)
+	}
+	return nil
+}
+
+// logPath returns the error or access log of the global configuration or of a site
+func logPath(kind string, site string) (name string, path string, err error) {
+	name = kind + " log"
+	if site != "" {
+		name = fmt.Sprintf("%s log of site %s", kind, site)
+		if path, _, err = commands.SiteLogPath(site, kind); err != nil {
+			return "", "", err
+		}
+	} else {
+		path = commands.LogPath(kind)
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("could not locate the nginx %s file", name)
+	}
+	return name, path, nil
+}
+
+// logsTail prints the last lines of the error or access log, of the
+// global configuration or of one site, and follows it with -f. As JSON
+// the lines are parsed; when following, one object is printed per line.
+func (c *cli) logsTail(args []string) error {
+	flags := flag.NewFlagSet("logs tail", flag.ContinueOnError)
+	lines := flags.Int("n", config.Get().TailLines, "number of lines")
+	follow := flags.Bool("f", false, "follow the log as it grows")
+	site := flags.String("site", "", "read the log of this site")
+	rest, err := parseArgs(flags, args, c.stderr)
+	if err != nil {
+		return err
+	}
+
+	kind := "error"
+	if len(rest) > 0 {
+		kind = rest[0]
+	}
+	if len(rest) > 1 || (kind != "error" && kind != "access") {
+		return errUsage("logs tail takes error or access")
+	}
+	name, path, err := logPath(kind, *site)
+	if err != nil {
+		return err
+	}
+
+	parse := lineParser(kind, path)
+	if !*follow {
+		output, ok := commands.TailFile(path, *lines)
+		if !ok {
+			return fmt.Errorf("could not read %s", path)
+		}
+		if c.json {
+			c.printJSON(newLogJSON(kind, path, strings.Split(strings.TrimRight(output, "\n"), "\n"), parse))
+			return nil
+		}
+		printOutput(c.stdout, output)
+		return nil
+	}
+
+	// Follow like the UI does, until interrupted
+	msg := commands.FollowLog(name, path, *lines)
+	var started commands.FollowStartedMsg
+	switch msg := msg.(type) {
+	case commands.FollowStartedMsg:
+		started = msg
+	case commands.OutputMsg:
+		return fmt.Errorf("%s", msg.Output)
+	default:
+		return fmt.Errorf("unexpected message %T", msg)
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		started.Follower.Stop()
+	}()
+
+	tail := started.Lines
+	for {
+		for _, line := range tail {
+			if c.json {
+				c.printJSONLine(parse(line))
+			} else {
+				fmt.Fprintln(c.stdout, line)
+			}
+		}
+		msg := commands.WaitForLogLines(started.Follower)()
+		next, ok := msg.(commands.LogLinesMsg)
+		if !ok {
+			return fmt.Errorf("unexpected message %T", msg)
+		}
+		if next.Err == logs.ErrStopped {
+			return nil
+		}
+		if next.Err != nil {
+			return next.Err
+		}
+		tail = next.Lines
+	}
+}
+
+// logsStats summarizes the access log over a time window, like the Traffic
+// view, and counts the error log entries of the last lines per level
+func (c *cli) logsStats(args []string) error {
+	flags := flag.NewFlagSet("logs stats", flag.ContinueOnError)
+	window := flags.Duration("window", time.Hour, "time window of the access log statistics")
+	rest, err := parseArgs(flags, args, c.stderr)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errUsage("logs stats takes no arguments")
+	}
+	if *window <= 0 {
+		return errUsage("--window must be positive")
+	}
+
+	var stats statsJSON
+	var failed []string
+	switch msg := commands.LoadTraffic(*window)().(type) {
+	case commands.TrafficMsg:
+		stats.Access = newTrafficJSON(msg)
+		if !c.json {
+			c.printTraffic(msg)
+		}
+	case commands.OutputMsg:
+		failed = append(failed, msg.Output)
+		stats.AccessError = firstLine(msg.Output)
+	}
+	switch msg := commands.ViewErrorLogs().(type) {
+	case commands.ErrorLogMsg:
+		stats.Errors = newErrorStatsJSON(msg)
+		if !c.json {
+			c.printErrorStats(stats.Errors)
+		}
+	case commands.OutputMsg:
+		failed = append(failed, msg.Output)
+		stats.ErrorsError = firstLine(msg.Output)
+	}
+
+	if c.json {
+		c.printJSON(stats)
+	} else {
+		for _, output := range failed {
+			printOutput(c.stderr, output)
+		}
+	}
+	if len(failed) > 0 {
+		return exitError(1)
+	}
+	return nil
+}
+
+func (c *cli) printTraffic(t commands.TrafficMsg) {
+	s := t.Stats
+	fmt.Fprintf(c.stdout, "Access log %s (format %s), last %s:\n", t.Path, t.Format, s.Window)
+	fmt.Fprintf(c.stdout, "  Requests: %d (%.2f/s)\n", s.Total, s.PerSec)
+	fmt.Fprintf(c.stdout, "  Status:   2xx %d  3xx %d  4xx %d  5xx %d\n", s.Classes[2], s.Classes[3], s.Classes[4], s.Classes[5])
+	if s.Latency.Samples > 0 {
+		fmt.Fprintf(c.stdout, "  Latency:  p50 %s  p95 %s  p99 %s\n", s.Latency.P50, s.Latency.P95, s.Latency.P99)
+	}
+	for _, p := range s.Paths {
+		fmt.Fprintf(c.stdout, "  %6d  %s\n", p.Count, p.Value)
+	}
+	if t.Partial {
+		fmt.Fprintln(c.stdout, "  (only the end of the log was read)")
+	}
+	fmt.Fprintln(c.stdout)
+}
+
+func (c *cli) printErrorStats(e *errorStatsJSON) {
+	fmt.Fprintf(c.stdout, "Error log %s, last %d lines:\n", e.Path, e.Lines)
+	for _, level := range logs.Levels {
+		if n := e.Levels[level]; n > 0 {
+			fmt.Fprintf(c.stdout, "  %-7s %d\n", level, n)
+		}
+	}
+	for _, g := range e.Groups {
+		fmt.Fprintf(c.stdout, "  %6d  [%s] %s\n", g.Count, g.Level, g.Message)
+	}
+}
+
+// firstLine returns the first line of a command's output, for JSON error fields
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(output, "\n")
+	return line
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useSites points config and discovery at temp site directories holding an
+// enabled site "blog" and a disabled site "shop". nginx itself is missing,
+// so only commands that don't run it can succeed.
+func useSites(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	available := filepath.Join(dir, "sites-available")
+	enabled := filepath.Join(dir, "sites-enabled")
+	for _, d := range []string{available, enabled} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, site := range []string{"blog", "shop"} {
+		if err := os.WriteFile(filepath.Join(available, site), []byte("server {}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(available, "blog"), filepath.Join(enabled, "blog")); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "nginx.conf")
+	if err := os.WriteFile(conf, []byte("events {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := filepath.Join(dir, "config.yml")
+	yml := "nginx:\n" +
+		"  binary: " + filepath.Join(dir, "missing-nginx") + "\n" +
+		"  conf_path: " + conf + "\n" +
+		"  sites_available: " + available + "\n" +
+		"  sites_enabled: " + enabled + "\n"
+	if err := os.WriteFile(settings, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Load(settings); err != nil {
+		t.Fatal(err)
+	}
+	discovery.Refresh()
+	t.Cleanup(discovery.Refresh)
+	return dir
+}
+
+func TestSitesToggleAlreadyInState(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"sites", "enable", "blog"}, "Site 'blog' is already enabled"},
+		{[]string{"sites", "disable", "shop"}, "Site 'shop' is already disabled"},
+		{[]string{"sites", "enable", "blog", "--output", "json"}, `"output": "Site 'blog' is already enabled"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			dir := useSites(t)
+			var stdout, stderr bytes.Buffer
+
+			if code := Run(tt.args, "text", &stdout, &stderr); code != 0 {
+				t.Fatalf("exit %d, stderr %q", code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.want)
+			}
+
+			// Nothing was touched or backed up
+			if target, err := os.Readlink(filepath.Join(dir, "sites-enabled", "blog")); err != nil || target != filepath.Join(dir, "sites-available", "blog") {
+				t.Errorf("blog link = %q, %v", target, err)
+			}
+			if _, err := os.Lstat(filepath.Join(dir, "sites-enabled", "shop")); err == nil {
+				t.Error("shop was enabled")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "config", "lazynginx", "backups")); err == nil {
+				t.Error("a backup was taken")
+			}
+		})
+	}
+}
+
+func TestSitesToggleUnknownSite(t *testing.T) {
+	useSites(t)
+	var stdout, stderr bytes.Buffer
+
+	if code := Run([]string{"sites", "enable", "missing"}, "text", &stdout, &stderr); code != 1 {
+		t.Errorf("exit %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), `no site named "missing"`) {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
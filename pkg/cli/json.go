@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"encoding/json"
+	"lazynginx/pkg/commands"
+	"lazynginx/pkg/logs"
+	"sort"
+	"time"
+)
+
+// The JSON forms of command results. Field names are snake_case and
+// durations are in seconds, so they stay stable for scripts whatever the
+// Go types look like.
+
+// errorStatsTop is how many error messages "logs stats" lists
+const errorStatsTop = 10
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+type resultJSON struct {
+	OK     bool   `json:"ok"`
+	Output string `json:"output"`
+}
+
+type attemptJSON struct {
+	Method string `json:"method"`
+	Output string `json:"output"`
+}
+
+type serviceJSON struct {
+	OK       bool          `json:"ok"`
+	Action   string        `json:"action"`
+	Done     string        `json:"done,omitempty"`
+	Method   string        `json:"method,omitempty"`
+	Output   string        `json:"output"`
+	Attempts []attemptJSON `json:"failed_attempts"`
+}
+
+func newServiceJSON(r commands.ServiceResult) serviceJSON {
+	s := serviceJSON{OK: r.OK, Action: r.Action, Done: r.Done, Method: r.Method, Output: r.Output, Attempts: []attemptJSON{}}
+	for _, a := range r.Attempts {
+		s.Attempts = append(s.Attempts, attemptJSON{Method: a.Method, Output: a.Output})
+	}
+	return s
+}
+
+type changeJSON struct {
+	OK            bool     `json:"ok"` // nginx -t accepted the change; otherwise it was rolled back
+	Action        string   `json:"action"`
+	Kind          string   `json:"kind"`
+	Name          string   `json:"name"`
+	Path          string   `json:"path,omitempty"`
+	Paths         []string `json:"paths,omitempty"`
+	Type          string   `json:"type,omitempty"`
+	Root          string   `json:"root,omitempty"`
+	Location      string   `json:"location,omitempty"`
+	Backends      string   `json:"backends,omitempty"`
+	Test          testJSON `json:"test"`
+	RollbackError string   `json:"rollback_error,omitempty"`
+}
+
+func newChangeJSON(c commands.Change) changeJSON {
+	change := changeJSON{
+		OK: c.Test.OK, Action: c.Action, Kind: c.Kind, Name: c.Name, Path: c.Path, Paths: c.Paths,
+		Type: c.Type, Root: c.Root, Location: c.Location, Backends: c.Backends, Test: newTestJSON(c.Test),
+	}
+	if c.RollbackErr != nil {
+		change.RollbackError = c.RollbackErr.Error()
+	}
+	return change
+}
+
+type statusJSON struct {
+	Running     bool    `json:"running"`
+	PID         int     `json:"pid,omitempty"`
+	Uptime      float64 `json:"uptime_seconds,omitempty"`
+	Manager     string  `json:"manager,omitempty"`
+	Description string  `json:"description"`
+}
+
+func newStatusJSON(s commands.Status) statusJSON {
+	return statusJSON{Running: s.Running, PID: s.PID, Uptime: s.Uptime.Seconds(), Manager: s.Manager, Description: s.Description}
+}
+
+type diagnosticJSON struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+type testJSON struct {
+	OK          bool             `json:"ok"`
+	Diagnostics []diagnosticJSON `json:"diagnostics"`
+	Output      string           `json:"output"`
+}
+
+func newTestJSON(r commands.TestResult) testJSON {
+	t := testJSON{OK: r.OK, Output: r.Output, Diagnostics: []diagnosticJSON{}}
+	for _, d := range r.Diagnostics {
+		t.Diagnostics = append(t.Diagnostics, diagnosticJSON{Level: d.Level, Message: d.Message, File: d.File, Line: d.Line})
+	}
+	return t
+}
+
+type serverJSON struct {
+	Line        int      `json:"line"`
+	Listen      []string `json:"listen"`
+	ServerNames []string `json:"server_names"`
+	Root        string   `json:"root,omitempty"`
+	Certificate string   `json:"certificate,omitempty"`
+}
+
+type siteJSON struct {
+	Name    string       `json:"name"`
+	Enabled bool         `json:"enabled"`
+	Path    string       `json:"path"`
+	Servers []serverJSON `json:"servers"`
+}
+
+func newSiteJSON(s commands.Site) siteJSON {
+	site := siteJSON{Name: s.Name, Enabled: s.Enabled, Path: s.Path, Servers: []serverJSON{}}
+	for _, server := range s.Servers {
+		site.Servers = append(site.Servers, serverJSON{
+			Line:        server.Line,
+			Listen:      nonNil(server.Listen),
+			ServerNames: nonNil(server.ServerNames),
+			Root:        server.Root,
+			Certificate: server.Certificate,
+		})
+	}
+	return site
+}
+
+type proxyJSON struct {
+	Host     string `json:"host"`
+	Location string `json:"location"`
+	Target   string `json:"target"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func newProxyJSON(p commands.Proxy) proxyJSON {
+	return proxyJSON{Host: p.Host, Location: p.Location, Target: p.Target, File: p.File, Line: p.Line}
+}
+
+// rawJSON is a log line that didn't parse
+type rawJSON struct {
+	Raw string `json:"raw"`
+}
+
+type errorEntryJSON struct {
+	Time       *time.Time `json:"time,omitempty"`
+	Level      string     `json:"level"`
+	PID        int        `json:"pid"`
+	TID        int        `json:"tid"`
+	Connection int64      `json:"connection,omitempty"`
+	Message    string     `json:"message"`
+	Client     string     `json:"client,omitempty"`
+	Server     string     `json:"server,omitempty"`
+	Request    string     `json:"request,omitempty"`
+	Upstream   string     `json:"upstream,omitempty"`
+	Host       string     `json:"host,omitempty"`
+	Raw        string     `json:"raw"`
+}
+
+func newErrorEntryJSON(e *logs.ErrorEntry) errorEntryJSON {
+	return errorEntryJSON{
+		Time: timeOrNil(e.Time), Level: e.Level, PID: e.PID, TID: e.TID, Connection: e.Connection,
+		Message: e.Message, Client: e.Client, Server: e.Server, Request: e.Request,
+		Upstream: e.Upstream, Host: e.Host, Raw: e.Raw,
+	}
+}
+
+type recordJSON struct {
+	Time                 *time.Time `json:"time,omitempty"`
+	RemoteAddr           string     `json:"remote_addr"`
+	Method               string     `json:"method,omitempty"`
+	URI                  string     `json:"uri,omitempty"`
+	Status               int        `json:"status,omitempty"`
+	BytesSent            int64      `json:"bytes_sent"`
+	Referer              string     `json:"referer,omitempty"`
+	UserAgent            string     `json:"user_agent,omitempty"`
+	Host                 string     `json:"host,omitempty"`
+	RequestTime          *float64   `json:"request_time,omitempty"`
+	UpstreamResponseTime *float64   `json:"upstream_response_time,omitempty"`
+	Raw                  string     `json:"raw"`
+}
+
+func newRecordJSON(r *logs.Record) recordJSON {
+	record := recordJSON{
+		Time: timeOrNil(r.Time), RemoteAddr: r.RemoteAddr, Method: r.Method, URI: r.URI,
+		Status: r.Status, BytesSent: r.BytesSent, Referer: r.Referer, UserAgent: r.UserAgent,
+		Host: r.Host, Raw: r.Raw,
+	}
+	if r.HasRequestTime {
+		seconds := r.RequestTime.Seconds()
+		record.RequestTime = &seconds
+	}
+	if r.HasUpstreamResponseTime {
+		seconds := r.UpstreamResponseTime.Seconds()
+		record.UpstreamResponseTime = &seconds
+	}
+	return record
+}
+
+// logJSON is the parsed tail of a log: one object per line
+type logJSON struct {
+	Kind  string `json:"kind"`
+	Path  string `json:"path"`
+	Lines []any  `json:"lines"`
+}
+
+func newLogJSON(kind string, path string, lines []string, parse func(line string) any) logJSON {
+	l := logJSON{Kind: kind, Path: path, Lines: []any{}}
+	for _, line := range lines {
+		if line != "" {
+			l.Lines = append(l.Lines, parse(line))
+		}
+	}
+	return l
+}
+
+// lineParser returns a function turning a line of the log into its JSON
+// form; lines that don't parse keep only their raw text
+func lineParser(kind string, path string) func(line string) any {
+	if kind == "error" {
+		return func(line string) any {
+			if entry, ok := logs.ParseErrorLine(line); ok {
+				return newErrorEntryJSON(entry)
+			}
+			return rawJSON{Raw: line}
+		}
+	}
+	format := commands.AccessLogFormat(path)
+	return func(line string) any {
+		if records, _ := commands.ParseAccessLog(format, []string{line}); len(records) == 1 {
+			return newRecordJSON(records[0])
+		}
+		return rawJSON{Raw: line}
+	}
+}
+
+type countJSON struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type percentilesJSON struct {
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+}
+
+type trafficJSON struct {
+	Path     string          `json:"path"`
+	Format   string          `json:"format"`
+	Window   float64         `json:"window_seconds"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Requests int             `json:"requests"`
+	PerSec   float64         `json:"requests_per_second"`
+	Status   map[string]int  `json:"status"`
+	Paths    []countJSON     `json:"top_paths"`
+	IPs      []countJSON     `json:"top_ips"`
+	Agents   []countJSON     `json:"top_user_agents"`
+	Latency  percentilesJSON `json:"request_time"`
+	Upstream percentilesJSON `json:"upstream_response_time"`
+	Skipped  int             `json:"skipped_lines"`
+	Partial  bool            `json:"partial"`
+}
+
+func newTrafficJSON(t commands.TrafficMsg) *trafficJSON {
+	s := t.Stats
+	return &trafficJSON{
+		Path: t.Path, Format: t.Format, Window: s.Window.Seconds(), From: s.From, To: s.To,
+		Requests: s.Total, PerSec: s.PerSec,
+		Status:   map[string]int{"1xx": s.Classes[1], "2xx": s.Classes[2], "3xx": s.Classes[3], "4xx": s.Classes[4], "5xx": s.Classes[5]},
+		Paths:    newCountsJSON(s.Paths),
+		IPs:      newCountsJSON(s.IPs),
+		Agents:   newCountsJSON(s.Agents),
+		Latency:  newPercentilesJSON(s.Latency),
+		Upstream: newPercentilesJSON(s.Upstream),
+		Skipped:  t.Skipped,
+		Partial:  t.Partial,
+	}
+}
+
+func newCountsJSON(counts []logs.Count) []countJSON {
+	list := []countJSON{}
+	for _, c := range counts {
+		list = append(list, countJSON{Value: c.Value, Count: c.Count})
+	}
+	return list
+}
+
+func newPercentilesJSON(p logs.Percentiles) percentilesJSON {
+	return percentilesJSON{Samples: p.Samples, P50: p.P50.Seconds(), P90: p.P90.Seconds(), P95: p.P95.Seconds(), P99: p.P99.Seconds(), Max: p.Max.Seconds()}
+}
+
+type errorGroupJSON struct {
+	Level    string    `json:"level"`
+	Message  string    `json:"message"`
+	Server   string    `json:"server,omitempty"`
+	Upstream string    `json:"upstream,omitempty"`
+	Count    int       `json:"count"`
+	First    time.Time `json:"first"`
+	Last     time.Time `json:"last"`
+}
+
+type errorStatsJSON struct {
+	Path    string           `json:"path"`
+	Lines   int              `json:"lines"` // lines read from the end of the log
+	Entries int              `json:"entries"`
+	Levels  map[string]int   `json:"levels"`
+	Groups  []errorGroupJSON `json:"top_messages"`
+}
+
+// newErrorStatsJSON counts entries per level and lists the most repeated messages
+func newErrorStatsJSON(e commands.ErrorLogMsg) *errorStatsJSON {
+	stats := &errorStatsJSON{Path: e.Path, Lines: e.Lines, Entries: len(e.Entries), Levels: map[string]int{}, Groups: []errorGroupJSON{}}
+	for _, entry := range e.Entries {
+		stats.Levels[entry.Level]++
+	}
+
+	groups := logs.GroupErrors(e.Entries, "")
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	for _, g := range groups[:min(len(groups), errorStatsTop)] {
+		stats.Groups = append(stats.Groups, errorGroupJSON{Level: g.Level, Message: g.Message, Server: g.Server, Upstream: g.Upstream, Count: g.Count, First: g.First, Last: g.Last})
+	}
+	return stats
+}
+
+type statsJSON struct {
+	Access      *trafficJSON    `json:"access,omitempty"`
+	AccessError string          `json:"access_error,omitempty"`
+	Errors      *errorStatsJSON `json:"errors,omitempty"`
+	ErrorsError string          `json:"errors_error,omitempty"`
+}
+
+// printJSON prints a value as indented JSON
+func (c *cli) printJSON(v any) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		out, _ = json.Marshal(errorJSON{Error: err.Error()})
+	}
+	c.stdout.Write(append(out, '\n'))
+}
+
+// printJSONLine prints a value as one line of JSON, for streams
+func (c *cli) printJSONLine(v any) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		out, _ = json.Marshal(errorJSON{Error: err.Error()})
+	}
+	c.stdout.Write(append(out, '\n'))
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// nonNil makes empty lists print as [] instead of null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/nginxconf"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AccessLogMsg carries the parsed tail, or a time span, of the access log
+type AccessLogMsg struct {
+	Path      string
+	Lines     int       // lines requested from the end of the log, or read from the span
+	Span      logs.Span // time span shown, empty for the end of the log
+	Truncated bool      // the span has more lines than were read
+	Output    string    // the lines as read
+	Format    string
+	Records   []*logs.Record
+	Skipped   int // lines that didn't match the log format
+
+	Site      string // site the log was opened for, "" for the global log
+	Inherited bool   // the site sets no access_log and writes to the global one
+}
+
+// AccessRecordsMsg carries the records of the end of an access log that
+// the filter searches, many more than the lines shown without one
+type AccessRecordsMsg struct {
+	Path    string
+	Records []*logs.Record
+	Skipped int  // lines that didn't match the log format
+	Partial bool // the log is longer than what was read
+	Err     error
+}
+
+// FilterReadBytes bounds how much of the access log a filter searches, the
+// same as the Traffic view reads
+const FilterReadBytes = trafficReadBytes
+
+func ViewAccessLogs() tea.Msg {
+	return ViewAccessLogFile(discovery.Get().AccessLog)
+}
+
+// ViewAccessLogFile shows the end of an access log, or of one of its rotated copies
+func ViewAccessLogFile(path string) tea.Msg {
+	lines := config.Get().TailLines
+	output, ok := TailFile(path, lines)
+	if !ok {
+		return OutputMsg{Output: "Could not locate nginx access log file.\n\n" + describePaths()}
+	}
+
+	format := AccessLogFormat(path)
+	records, skipped := ParseAccessLog(format, strings.Split(output, "\n"))
+	return AccessLogMsg{Path: path, Lines: lines, Output: output, Format: format.Name, Records: records, Skipped: skipped}
+}
+
+// ViewAccessLogSpan shows the lines of an access log, or of one of its
+// rotated copies, that were written in a time span
+func ViewAccessLogSpan(path string, span logs.Span) tea.Msg {
+	format := AccessLogFormat(path)
+	lines, truncated, err := readSpan(path, span, func(line string) (time.Time, bool) {
+		record, ok := format.Parse(line)
+		if !ok || record.Time.IsZero() {
+			return time.Time{}, false
+		}
+		return record.Time, true
+	})
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to read %s: %s\n\nYou may need sudo/administrator privileges", path, err.Error())}
+	}
+
+	records, skipped := ParseAccessLog(format, lines)
+	return AccessLogMsg{Path: path, Lines: len(lines), Span: span, Truncated: truncated, Output: strings.Join(lines, "\n"), Format: format.Name, Records: records, Skipped: skipped}
+}
+
+// LoadAccessRecords parses the last 32MB of an access log for the filter
+func LoadAccessRecords(path string) tea.Msg {
+	lines, partial, err := logs.ReadLast(path, FilterReadBytes)
+	if err != nil {
+		return AccessRecordsMsg{Path: path, Err: err}
+	}
+
+	records, skipped := ParseAccessLog(AccessLogFormat(path), lines)
+	return AccessRecordsMsg{Path: path, Records: records, Skipped: skipped, Partial: partial}
+}
+
+// AccessLogFormat returns the log_format the access log at path is written
+// with, falling back to combined when the configuration can't be read.
+// A rotated copy was written with the format of the live log.
+func AccessLogFormat(path string) *logs.Format {
+	paths := discovery.Get()
+
+	var directives []*nginxconf.Directive
+	if confPath, err := FindNginxConfigPath(); err == nil {
+		if tree, err := nginxconf.Resolve(confPath, paths.ConfPrefix); err == nil {
+			directives = tree.Directives
+		}
+	}
+	return logs.AccessFormat(directives, logs.LivePath(path), paths.Prefix)
+}
+
+// ParseAccessLog parses access log lines with format. Lines that don't
+// match it (e.g. written before the format changed) are counted as skipped.
+func ParseAccessLog(format *logs.Format, lines []string) (records []*logs.Record, skipped int) {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		record, ok := format.Parse(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, skipped
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/backup"
+	"lazynginx/pkg/diff"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// noBackups is the Backups submenu placeholder when the store is empty
+const noBackups = "No backups yet"
+
+// BackupsMsg carries the labels of the snapshots in the backup store, newest first
+type BackupsMsg struct {
+	Labels []string
+	Dir    string // where the store is, for the status line
+	Err    error
+}
+
+// LoadBackups lists the snapshots in the backup store
+func LoadBackups() tea.Msg {
+	snapshots, err := backup.List()
+	if err != nil {
+		return BackupsMsg{Dir: backup.Dir(), Err: err}
+	}
+
+	var labels []string
+	for _, snapshot := range snapshots {
+		labels = append(labels, snapshot.Label())
+	}
+	return BackupsMsg{Labels: labels, Dir: backup.Dir()}
+}
+
+// ViewBackup shows the files in a snapshot and how each one changed since
+func ViewBackup(label string) tea.Msg {
+	if label == "" || label == noBackups {
+		return OutputMsg{Output: "No backup selected"}
+	}
+
+	snapshot, err := backup.Get(backup.IDFromLabel(label))
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Could not read backup: %s", err.Error())}
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Backup:  %s\n", snapshot.ID))
+	s.WriteString(fmt.Sprintf("Taken:   %s\n", snapshot.Timestamp.Format("2006-01-02 15:04:05")))
+	s.WriteString(fmt.Sprintf("Reason:  %s\n", snapshot.Reason))
+	s.WriteString("\nFiles:\n")
+	for _, file := range snapshot.Files {
+		if file.Symlink != "" {
+			s.WriteString(fmt.Sprintf("  - %s -> %s\n", file.Path, file.Symlink))
+		} else {
+			s.WriteString(fmt.Sprintf("  - %s\n", file.Path))
+		}
+	}
+	s.WriteString("\nPress Enter to restore this backup.\n")
+
+	for _, file := range snapshot.Files {
+		s.WriteString("\n" + strings.Repeat("─", 50) + "\n")
+		s.WriteString(fmt.Sprintf("Changes to %s since this backup:\n\n", file.Path))
+		s.WriteString(describeBackupChange(*snapshot, file))
+	}
+
+	return OutputMsg{Output: s.String()}
+}
+
+// describeBackupChange diffs a backed up file against what is on disk now
+func describeBackupChange(snapshot backup.Snapshot, file backup.File) string {
+	info, err := os.Lstat(file.Path)
+	if err != nil {
+		return "The file no longer exists; restoring will recreate it.\n"
+	}
+
+	if file.Symlink != "" {
+		target, _ := os.Readlink(file.Path)
+		if info.Mode()&os.ModeSymlink != 0 && target == file.Symlink {
+			return "No changes.\n"
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Sprintf("The symlink now points to %s instead of %s.\n", target, file.Symlink)
+		}
+		return "The symlink was replaced by a regular file.\n"
+	}
+
+	saved, err := snapshot.Content(file)
+	if err != nil {
+		return fmt.Sprintf("Could not read the backed up copy: %s\n", err.Error())
+	}
+	current, err := os.ReadFile(file.Path)
+	if err != nil {
+		return fmt.Sprintf("Could not read the current file: %s\n", err.Error())
+	}
+
+	unified := diff.Unified(file.Path+" (backup "+snapshot.ID+")", file.Path+" (current)", saved, string(current), 3)
+	if unified == "" {
+		return "No changes.\n"
+	}
+	return unified
+}
+
+// RestoreBackup writes the files of a snapshot back and tests the result.
+// The current files are backed up first, so a restore can itself be undone.
+func RestoreBackup(label string) tea.Msg {
+	if label == "" || label == noBackups {
+		return OutputMsg{Output: "No backup selected"}
+	}
+
+	snapshot, err := backup.Get(backup.IDFromLabel(label))
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Could not read backup: %s", err.Error())}
+	}
+
+	tx, err := BeginTransaction("restore of "+snapshot.ID, snapshot.Paths()...)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to restore backup: %s\n\nYou may need sudo/administrator privileges", err.Error())}
+	}
+
+	if err := snapshot.Restore(); err != nil {
+		if tx.Rollback() == nil {
+			tx.DiscardBackup()
+		}
+		return OutputMsg{Output: fmt.Sprintf("Failed to restore backup: %s\n\nYou may need sudo/administrator privileges", err.Error())}
+	}
+
+	return tx.Validate(Change{Action: "restore", Kind: "backup", Name: snapshot.ID, Paths: snapshot.Paths()})
+}
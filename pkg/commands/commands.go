@@ -2,9 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/nginxconf"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,10 +26,37 @@ type OutputMsg struct {
 }
 
 type ConfigViewMsg struct {
-	Output   string
+	Header   string // text above the configuration: its path, a summary
+	Config   string // the configuration text, shown with syntax colors
 	Path     string
 	Type     string // "main" or "site"
 	SiteName string
+	ScrollTo int // line of the view to scroll to, 0 for the top
+	Line     int // line of the file the view points at, 0 for none
+
+	// Positions holds the file and line shown on each line of the view, so
+	// the editor can open where the user is looking. Lines that show no
+	// file line have a zero Position.
+	Positions []nginxconf.Position
+}
+
+// filePositions returns the Positions of a view showing header and then
+// the lines of a file
+func filePositions(header string, path string, content string) []nginxconf.Position {
+	positions := make([]nginxconf.Position, strings.Count(header, "\n"))
+	for i := range strings.Split(content, "\n") {
+		positions = append(positions, nginxconf.Position{File: path, Line: i + 1})
+	}
+	return positions
+}
+
+// nginxBinary returns the discovered nginx executable, or "nginx" to let
+// the shell search PATH when discovery found nothing
+func nginxBinary() string {
+	if binary := discovery.Get().Binary; binary != "" {
+		return binary
+	}
+	return "nginx"
 }
 
 // IsAdmin checks if the program is running with administrator/root privileges
@@ -39,25 +72,12 @@ func IsAdmin() bool {
 	}
 }
 
-// Commands
-func CheckNginxStatus() tea.Msg {
-	// ASCII art header (centered)
-	asciiArt := `
-           _                             _            
-          | |                           (_)           
-          | | __ _ _____   _ _ __   __ _ _ _ __ __  __
-          | |/ _` + "`" + ` |_  / | | | '_ \ / _` + "`" + ` | | '_ \\ \/ /
-          | | (_| |/ /| |_| | | | | (_| | | | | |>  < 
-          |_|\__,_/___|\__, |_| |_|\__, |_|_| |_/_/\_\
-                        __/ |       __/ |              
-                       |___/       |___/               
-
-`
-
+// processState reports whether nginx is running, what manages it and a
+// one-line description
+func processState() (running bool, manager string, description string) {
 	// Check if nginx binary exists
-	_, err := exec.LookPath("nginx")
-	if err != nil {
-		return StatusMsg{Status: asciiArt + "⚠️  Nginx binary not found in PATH"}
+	if discovery.Get().Binary == "" {
+		return false, "", "⚠️  Nginx binary not found in PATH"
 	}
 
 	// Windows: Check if nginx process is running
@@ -66,10 +86,10 @@ func CheckNginxStatus() tea.Msg {
 	if err == nil {
 		// tasklist command succeeded, check if nginx.exe is in the output
 		if strings.Contains(strings.ToLower(string(output)), "nginx.exe") {
-			return StatusMsg{Status: asciiArt + "✓ Nginx is running"}
+			return true, "windows", "✓ Nginx is running"
 		}
 		// On Windows, if tasklist worked but nginx.exe not found, it's not running
-		return StatusMsg{Status: asciiArt + "⚠️  Nginx is not running"}
+		return false, "windows", "⚠️  Nginx is not running"
 	}
 
 	// Unix/Linux: Try systemctl first (most common)
@@ -78,9 +98,9 @@ func CheckNginxStatus() tea.Msg {
 	if err == nil {
 		status := strings.TrimSpace(string(output))
 		if status == "active" {
-			return StatusMsg{Status: asciiArt + "✓ Nginx is running (systemd)"}
+			return true, "systemd", "✓ Nginx is running (systemd)"
 		} else if status == "inactive" {
-			return StatusMsg{Status: asciiArt + "⚠️  Nginx is not running (systemd)"}
+			return false, "systemd", "⚠️  Nginx is not running (systemd)"
 		}
 		// If systemctl returned something else, fall through to other checks
 	}
@@ -89,7 +109,7 @@ func CheckNginxStatus() tea.Msg {
 	cmd = exec.Command("pgrep", "-x", "nginx")
 	err = cmd.Run()
 	if err == nil {
-		return StatusMsg{Status: asciiArt + "✓ Nginx is running"}
+		return true, "process", "✓ Nginx is running"
 	}
 
 	// Try ps command as fallback
@@ -111,268 +131,93 @@ func CheckNginxStatus() tea.Msg {
 				lowerCmd := strings.ToLower(command)
 				// Check for actual nginx processes, not lazynginx
 				if (strings.Contains(lowerCmd, "/nginx") || strings.Contains(lowerCmd, " nginx") || strings.HasPrefix(lowerCmd, "nginx")) && !strings.Contains(lowerCmd, "lazynginx") {
-					return StatusMsg{Status: asciiArt + "✓ Nginx is running"}
+					return true, "process", "✓ Nginx is running"
 				}
 			}
 		}
 		// ps command worked but nginx not in output
-		return StatusMsg{Status: asciiArt + "⚠️  Nginx is not running"}
+		return false, "process", "⚠️  Nginx is not running"
 	}
 
-	return StatusMsg{Status: asciiArt + "⚠️  Nginx is not running"}
+	return false, "process", "⚠️  Nginx is not running"
 }
 
-func StartNginx() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
-
-	// Try different methods based on OS
-	// Windows
-	cmd = exec.Command("net", "start", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx started successfully\n\n" + string(output)}
-	}
-	windowsErr := string(output)
-
-	// Unix/Linux with systemd (with sudo)
-	cmd = exec.Command("sudo", "systemctl", "start", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx started successfully\n\n" + string(output)}
-	}
-	systemdSudoErr := string(output)
-
-	// Unix/Linux with systemd (without sudo)
-	cmd = exec.Command("systemctl", "start", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx started successfully\n\n" + string(output)}
-	}
-
-	// Direct nginx command (with sudo)
-	cmd = exec.Command("sudo", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx started successfully\n\n" + string(output)}
-	}
-	nginxSudoErr := string(output)
-
-	// Direct nginx command (without sudo)
-	cmd = exec.Command("nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx started successfully\n\n" + string(output)}
-	}
-	nginxErr := string(output)
-
-	// All methods failed, show detailed error
-	errorMsg := fmt.Sprintf("Failed to start nginx. Tried the following methods:\n\n")
-	errorMsg += fmt.Sprintf("1. Windows (net start): %s\n", windowsErr)
-	errorMsg += fmt.Sprintf("2. Systemd (sudo): %s\n", systemdSudoErr)
-	errorMsg += fmt.Sprintf("3. Direct nginx (sudo): %s\n", nginxSudoErr)
-	errorMsg += fmt.Sprintf("4. Direct nginx (no sudo): %s\n\n", nginxErr)
-	errorMsg += "Note: You may need to:\n"
-	errorMsg += "- Run lazynginx with sudo: sudo ./lazynginx\n"
-	errorMsg += "- Or configure passwordless sudo for nginx commands\n"
-	errorMsg += "- Or run as administrator on Windows"
-
-	return OutputMsg{Output: errorMsg}
+// TestMsg carries the outcome of nginx -t
+type TestMsg struct {
+	Result TestResult
 }
 
-func StopNginx() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
-
-	// Windows
-	cmd = exec.Command("net", "stop", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx stopped successfully\n\n" + string(output)}
-	}
-	windowsErr := string(output)
-
-	// Unix/Linux with systemd
-	cmd = exec.Command("sudo", "systemctl", "stop", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx stopped successfully\n\n" + string(output)}
-	}
-	systemdErr := string(output)
-
-	// Try without sudo for systemd (if user has permissions)
-	cmd = exec.Command("systemctl", "stop", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx stopped successfully\n\n" + string(output)}
-	}
-
-	// Direct nginx command with sudo
-	cmd = exec.Command("sudo", "nginx", "-s", "stop")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx stopped successfully\n\n" + string(output)}
-	}
-	sudoNginxErr := string(output)
-
-	// Try without sudo for nginx command
-	cmd = exec.Command("nginx", "-s", "stop")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx stopped successfully\n\n" + string(output)}
-	}
-	nginxErr := string(output)
-
-	// Force kill nginx processes with pkill (Linux/Unix)
-	cmd = exec.Command("sudo", "pkill", "-9", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx stopped successfully (force killed)\n\n" + string(output)}
-	}
-	pkillErr := string(output)
-
-	// All methods failed, show detailed error
-	errorMsg := fmt.Sprintf("Failed to stop nginx. Tried the following methods:\n\n")
-	errorMsg += fmt.Sprintf("1. Windows (net stop): %s\n", windowsErr)
-	errorMsg += fmt.Sprintf("2. Systemd (sudo): %s\n", systemdErr)
-	errorMsg += fmt.Sprintf("3. Direct nginx (sudo): %s\n", sudoNginxErr)
-	errorMsg += fmt.Sprintf("4. Direct nginx (no sudo): %s\n", nginxErr)
-	errorMsg += fmt.Sprintf("5. Force kill (sudo pkill): %s\n\n", pkillErr)
-	errorMsg += "Note: You may need to:\n"
-	errorMsg += "- Run lazynginx with sudo: sudo ./lazynginx\n"
-	errorMsg += "- Or configure passwordless sudo for nginx commands\n"
-	errorMsg += "- Or run as administrator on Windows"
-
-	return OutputMsg{Output: errorMsg}
+func TestNginxConfig() tea.Msg {
+	return TestMsg{Result: TestConfig()}
 }
 
-func RestartNginx() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
-
-	// Windows
-	cmd = exec.Command("net", "stop", "nginx")
-	cmd.Run()
-	cmd = exec.Command("net", "start", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx restarted successfully\n\n" + string(output)}
+// ViewDiagnostic opens the file an nginx -t error or warning points at,
+// scrolled to its line
+func ViewDiagnostic(d nginxconf.Diagnostic) tea.Msg {
+	if d.File == "" {
+		return OutputMsg{Output: fmt.Sprintf("nginx names no file for this message:\n\n[%s] %s", d.Level, d.Message)}
 	}
-	windowsErr := string(output)
-
-	// Unix/Linux with systemd (with sudo)
-	cmd = exec.Command("sudo", "systemctl", "restart", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx restarted successfully\n\n" + string(output)}
-	}
-	systemdSudoErr := string(output)
-
-	// Unix/Linux with systemd (without sudo)
-	cmd = exec.Command("systemctl", "restart", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx restarted successfully\n\n" + string(output)}
-	}
-
-	// Direct nginx command (reload with sudo)
-	cmd = exec.Command("sudo", "nginx", "-s", "reload")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx reloaded successfully\n\n" + string(output)}
-	}
-	nginxSudoErr := string(output)
-
-	// Direct nginx command (reload without sudo)
-	cmd = exec.Command("nginx", "-s", "reload")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx reloaded successfully\n\n" + string(output)}
-	}
-	nginxErr := string(output)
-
-	// All methods failed, show detailed error
-	errorMsg := fmt.Sprintf("Failed to restart nginx. Tried the following methods:\n\n")
-	errorMsg += fmt.Sprintf("1. Windows (net restart): %s\n", windowsErr)
-	errorMsg += fmt.Sprintf("2. Systemd (sudo): %s\n", systemdSudoErr)
-	errorMsg += fmt.Sprintf("3. Direct nginx reload (sudo): %s\n", nginxSudoErr)
-	errorMsg += fmt.Sprintf("4. Direct nginx reload (no sudo): %s\n\n", nginxErr)
-	errorMsg += "Note: You may need to:\n"
-	errorMsg += "- Run lazynginx with sudo: sudo ./lazynginx\n"
-	errorMsg += "- Or configure passwordless sudo for nginx commands\n"
-	errorMsg += "- Or run as administrator on Windows"
-
-	return OutputMsg{Output: errorMsg}
+	header := fmt.Sprintf("[%s] %s\nin %s:%d\n%s\n", d.Level, d.Message, d.File, d.Line, strings.Repeat("─", 50))
+	return viewFileAt(d.File, d.Line, header)
 }
 
-func ReloadNginx() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
-
-	// Windows
-	cmd = exec.Command("nginx", "-s", "reload")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx configuration reloaded successfully\n\n" + string(output)}
-	}
-
-	// Unix/Linux with systemd
-	cmd = exec.Command("sudo", "systemctl", "reload", "nginx")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx configuration reloaded successfully\n\n" + string(output)}
-	}
-
-	// Direct nginx command
-	cmd = exec.Command("sudo", "nginx", "-s", "reload")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		return OutputMsg{Output: "Nginx configuration reloaded successfully\n\n" + string(output)}
-	}
+// TestResult is the outcome of nginx -t
+type TestResult struct {
+	OK          bool
+	Output      string
+	Diagnostics []nginxconf.Diagnostic // errors and warnings with their file:line
+}
 
-	return OutputMsg{Output: fmt.Sprintf("Failed to reload nginx:\n%s\n\nNote: You may need to run with sudo/administrator privileges", string(output))}
+// TestConfig runs nginx -t and parses the errors and warnings it reports
+func TestConfig() TestResult {
+	ok, output := runTest()
+	return TestResult{OK: ok, Output: output, Diagnostics: nginxconf.ParseDiagnostics(output)}
 }
 
-func TestNginxConfig() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
+// runTest runs nginx -t (retrying with sudo) and returns whether the
+// configuration is valid along with the nginx output
+func runTest() (bool, string) {
+	args := []string{"-t"}
+	// Test the configured file instead of the compiled-in one
+	if confPath := config.Get().Nginx.ConfPath; confPath != "" {
+		args = append(args, "-c", confPath)
+	}
 
 	// Test configuration
-	cmd = exec.Command("nginx", "-t")
-	output, err = cmd.CombinedOutput()
+	cmd := exec.Command(nginxBinary(), args...)
+	output, err := cmd.CombinedOutput()
 	if err == nil {
-		return OutputMsg{Output: "Configuration test passed!\n\n" + string(output)}
+		return true, string(output)
+	}
+	firstOutput := string(output)
+	if firstOutput == "" {
+		// Nothing printed, e.g. the binary couldn't be started
+		firstOutput = err.Error()
 	}
 
 	// Try with sudo
-	cmd = exec.Command("sudo", "nginx", "-t")
+	cmd = exec.Command("sudo", append([]string{nginxBinary()}, args...)...)
 	output, err = cmd.CombinedOutput()
 	if err == nil {
-		return OutputMsg{Output: "Configuration test passed!\n\n" + string(output)}
+		return true, string(output)
 	}
 
-	return OutputMsg{Output: fmt.Sprintf("Configuration test failed:\n%s", string(output))}
+	// sudo itself missing or refused: report the direct attempt
+	if len(output) == 0 {
+		return false, firstOutput
+	}
+	return false, string(output)
 }
 
 func FindNginxConfigPath() (string, error) {
-	configPaths := []string{
-		"/etc/nginx/nginx.conf",
-		"C:\\nginx\\conf\\nginx.conf",
-		"/usr/local/nginx/conf/nginx.conf",
+	path := discovery.Get().ConfPath
+	if path == "" {
+		return "", fmt.Errorf("could not locate nginx configuration file")
 	}
-
-	for _, path := range configPaths {
-		if _, err := os.Stat(path); err == nil {
-			return path, nil
-		}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("could not read nginx configuration file %s: %v", path, err)
 	}
-
-	return "", fmt.Errorf("could not locate nginx configuration file")
+	return path, nil
 }
 
 func FindSiteConfigPath(siteName string) (string, error) {
@@ -380,15 +225,20 @@ func FindSiteConfigPath(siteName string) (string, error) {
 		return "", fmt.Errorf("invalid site name")
 	}
 
-	sitePaths := []string{
-		"/etc/nginx/sites-available/" + siteName,
-		"/etc/nginx/sites-enabled/" + siteName,
-		"C:\\nginx\\conf\\sites-available\\" + siteName,
-		"/usr/local/nginx/sites-available/" + siteName,
+	for _, dir := range discovery.Get().SiteDirs() {
+		path := filepath.Join(dir, siteName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		// Disabled conf.d site
+		if _, err := os.Stat(path + disabledSuffix); err == nil {
+			return path + disabledSuffix, nil
+		}
 	}
 
-	for _, path := range sitePaths {
-		if _, err := os.Stat(path); err == nil {
+	// Fall back to files pulled in by include directives (e.g. conf.d/*.conf)
+	for _, path := range includedSiteFiles() {
+		if filepath.Base(path) == siteName {
 			return path, nil
 		}
 	}
@@ -402,92 +252,110 @@ func ViewNginxConfig() tea.Msg {
 	var err error
 
 	if path, findErr := FindNginxConfigPath(); findErr == nil {
+		// Show the effective configuration (all includes expanded) like nginx -T
+		tree, resolveErr := nginxconf.Resolve(path, discovery.Get().ConfPrefix)
+		if resolveErr == nil {
+			header := fmt.Sprintf("Nginx Configuration (%s):\n%s\n\n", path, summarizeTree(tree))
+			dump, positions := tree.DumpPositions()
+			return ConfigViewMsg{
+				Header:    header,
+				Config:    dump,
+				Path:      path,
+				Type:      "main",
+				Positions: append(make([]nginxconf.Position, strings.Count(header, "\n")), positions...),
+			}
+		}
+
+		// Main file doesn't parse: show the error and the raw file
 		content, readErr := os.ReadFile(path)
 		if readErr == nil {
+			header := fmt.Sprintf("Nginx Configuration (%s):\n⚠️  Syntax error: %s\n\n", path, resolveErr.Error())
 			return ConfigViewMsg{
-				Output: fmt.Sprintf("Nginx Configuration (%s):\n\n%s", path, string(content)),
-				Path:   path,
-				Type:   "main",
+				Header:    header,
+				Config:    string(content),
+				Path:      path,
+				Type:      "main",
+				Positions: filePositions(header, path, string(content)),
 			}
 		}
 	}
 
 	// Try to get config path from nginx -V
-	cmd = exec.Command("nginx", "-V")
+	cmd = exec.Command(nginxBinary(), "-V")
 	output, err = cmd.CombinedOutput()
 	if err == nil {
 		return OutputMsg{Output: fmt.Sprintf("Nginx version and configuration paths:\n\n%s\n\nNote: Use the --prefix path shown above to locate nginx.conf", string(output))}
 	}
 
-	return OutputMsg{Output: "Could not locate nginx configuration file.\n\nCommon locations:\n- /etc/nginx/nginx.conf (Linux)\n- C:\\nginx\\conf\\nginx.conf (Windows)\n- /usr/local/nginx/conf/nginx.conf (macOS/Unix)"}
+	return OutputMsg{Output: "Could not locate nginx configuration file.\n\n" + describePaths()}
 }
 
-func ViewErrorLogs() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
-
-	// Try common log locations
-	logPaths := []string{
-		"/var/log/nginx/error.log",
-		"C:\\nginx\\logs\\error.log",
-		"/usr/local/nginx/logs/error.log",
+// describePaths lists what path discovery found, for "not found" messages
+func describePaths() string {
+	paths := discovery.Get()
+	notFound := func(value string) string {
+		if value == "" {
+			return "(not found)"
+		}
+		return value
 	}
 
-	for _, path := range logPaths {
-		if _, err := os.Stat(path); err == nil {
-			cmd = exec.Command("tail", "-n", "50", path)
-			output, err = cmd.CombinedOutput()
-			if err == nil {
-				return OutputMsg{Output: fmt.Sprintf("Last 50 lines of error log (%s):\n\n%s", path, string(output))}
-			}
-			// If tail doesn't work, try reading file directly
-			content, err := os.ReadFile(path)
-			if err == nil {
-				lines := strings.Split(string(content), "\n")
-				start := 0
-				if len(lines) > 50 {
-					start = len(lines) - 50
-				}
-				return OutputMsg{Output: fmt.Sprintf("Last 50 lines of error log (%s):\n\n%s", path, strings.Join(lines[start:], "\n"))}
-			}
+	return fmt.Sprintf("Discovered paths:\n- nginx binary: %s\n- prefix: %s\n- nginx.conf: %s\n- error log: %s\n- access log: %s",
+		notFound(paths.Binary), notFound(paths.Prefix), notFound(paths.ConfPath), notFound(paths.ErrorLog), notFound(paths.AccessLog))
+}
+
+// summarizeTree returns an overview of the effective configuration
+// followed by any problems found while following includes
+func summarizeTree(tree *nginxconf.Tree) string {
+	directives := 0
+	nginxconf.Walk(tree.Directives, func(d *nginxconf.Directive, parents []*nginxconf.Directive) bool {
+		if !d.IsComment() {
+			directives++
 		}
-	}
+		return true
+	})
 
-	return OutputMsg{Output: "Could not locate nginx error log file.\n\nCommon locations:\n- /var/log/nginx/error.log (Linux)\n- C:\\nginx\\logs\\error.log (Windows)\n- /usr/local/nginx/logs/error.log (macOS/Unix)"}
+	summary := fmt.Sprintf("%d files, %d directives, %d server blocks",
+		len(tree.Files), directives, len(nginxconf.Find(tree.Directives, "server")))
+	for _, err := range tree.Errors {
+		summary += "\n⚠️  " + err.Error()
+	}
+	return summary
 }
 
-func ViewAccessLogs() tea.Msg {
-	var cmd *exec.Cmd
-	var output []byte
+// TailFile returns the last n lines of a file using tail, or by reading
+// the file directly where tail isn't available
+func TailFile(path string, n int) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
 
-	// Try common log locations
-	logPaths := []string{
-		"/var/log/nginx/access.log",
-		"C:\\nginx\\logs\\access.log",
-		"/usr/local/nginx/logs/access.log",
+	// Rotated logs compressed by logrotate
+	if strings.HasSuffix(path, ".gz") {
+		lines, err := logs.Tail(path, n)
+		return strings.Join(lines, "\n"), err == nil
 	}
 
-	for _, path := range logPaths {
-		if _, err := os.Stat(path); err == nil {
-			cmd = exec.Command("tail", "-n", "50", path)
-			output, err = cmd.CombinedOutput()
-			if err == nil {
-				return OutputMsg{Output: fmt.Sprintf("Last 50 lines of access log (%s):\n\n%s", path, string(output))}
-			}
-			// If tail doesn't work, try reading file directly
-			content, err := os.ReadFile(path)
-			if err == nil {
-				lines := strings.Split(string(content), "\n")
-				start := 0
-				if len(lines) > 50 {
-					start = len(lines) - 50
-				}
-				return OutputMsg{Output: fmt.Sprintf("Last 50 lines of access log (%s):\n\n%s", path, strings.Join(lines[start:], "\n"))}
-			}
-		}
+	cmd := exec.Command("tail", "-n", strconv.Itoa(n), path)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return string(output), true
 	}
 
-	return OutputMsg{Output: "Could not locate nginx access log file.\n\nCommon locations:\n- /var/log/nginx/access.log (Linux)\n- C:\\nginx\\logs\\access.log (Windows)\n- /usr/local/nginx/logs/access.log (macOS/Unix)"}
+	// If tail doesn't work, try reading file directly
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(string(content), "\n")
+	start := 0
+	if len(lines) > n {
+		start = len(lines) - n
+	}
+	return strings.Join(lines[start:], "\n"), true
 }
 
 // Sites commands
@@ -496,39 +364,79 @@ type ModelInterface interface {
 	SetSubMenus(index int, items []string)
 }
 
-func LoadSites(m ModelInterface) tea.Cmd {
-	return func() tea.Msg {
-		var sites []string
-
-		// Try common sites directories
-		sitePaths := []string{
-			"/etc/nginx/sites-available",
-			"/etc/nginx/sites-enabled",
-			"C:\\nginx\\conf\\sites-available",
-			"/usr/local/nginx/sites-available",
-		}
-
-		for _, path := range sitePaths {
-			entries, err := os.ReadDir(path)
-			if err == nil {
-				for _, entry := range entries {
-					if !entry.IsDir() && entry.Name() != "default" {
-						sites = append(sites, entry.Name())
-					}
-				}
-				if len(sites) > 0 {
-					// Prepend "Add site" to the sites list
-					m.SetSubMenus(2, append([]string{"Add site"}, sites...))
-					return StatusMsg{Status: fmt.Sprintf("Found %d sites", len(sites))}
+// SitesMsg carries the sites found and whether each one is enabled
+type SitesMsg struct {
+	Sites  []string
+	States map[string]bool
+}
+
+func LoadSites() tea.Msg {
+	sites := ListSites()
+	states := make(map[string]bool, len(sites))
+	for _, site := range sites {
+		states[site] = SiteEnabled(site)
+	}
+	return SitesMsg{Sites: sites, States: states}
+}
+
+// ListSites returns the names of the sites in the site directories and
+// of the other files the configuration includes that define server blocks
+func ListSites() []string {
+	var sites []string
+	known := make(map[string]bool)
+
+	// sites-available, sites-enabled and conf.d as found by discovery.
+	// A site enabled through a symlink has the same name in both directories.
+	for _, path := range discovery.Get().SiteDirs() {
+		entries, err := os.ReadDir(path)
+		if err == nil {
+			for _, entry := range entries {
+				// Disabled conf.d files are listed under their enabled name
+				name := siteNameFromFile(entry.Name())
+				// Skip snippets and other files that don't define a server block
+				if !entry.IsDir() && name != "default" && !known[name] && hasServerBlock(filepath.Join(path, entry.Name())) {
+					known[name] = true
+					sites = append(sites, name)
 				}
 			}
 		}
+	}
+
+	// Add sites pulled in by include directives that don't live in one
+	// of the sites directories
+	for _, file := range includedSiteFiles() {
+		site := filepath.Base(file)
+		if !known[site] {
+			known[site] = true
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
 
-		// If no sites found, keep "Add site" option
-		m.SetSubMenus(2, []string{"Add site", "No sites found"})
+// includedSiteFiles returns the files of the effective configuration that
+// define server blocks, excluding the main nginx.conf, in include order
+func includedSiteFiles() []string {
+	var files []string
+
+	path, err := FindNginxConfigPath()
+	if err != nil {
+		return files
+	}
+	tree, err := nginxconf.Resolve(path, discovery.Get().ConfPrefix)
+	if err != nil {
+		return files
+	}
 
-		return StatusMsg{Status: "No sites configured"}
+	seen := make(map[string]bool)
+	for _, conf := range tree.Files {
+		if conf.File == tree.Main || seen[conf.File] || len(nginxconf.Find(conf.Directives, "server")) == 0 {
+			continue
+		}
+		seen[conf.File] = true
+		files = append(files, conf.File)
 	}
+	return files
 }
 
 func ViewSiteConfig(siteName string) tea.Msg {
@@ -540,85 +448,111 @@ func ViewSiteConfig(siteName string) tea.Msg {
 	if findErr == nil {
 		content, readErr := os.ReadFile(path)
 		if readErr == nil {
+			summary, summaryLines := summarizeSite(path, string(content))
+			header := fmt.Sprintf("Site Configuration: %s\n\nPath: %s\n\n", siteName, path)
+			positions := make([]nginxconf.Position, strings.Count(header, "\n"))
+			for _, line := range summaryLines {
+				if line > 0 {
+					positions = append(positions, nginxconf.Position{File: path, Line: line})
+				} else {
+					positions = append(positions, nginxconf.Position{})
+				}
+			}
+			separator := "\n" + strings.Repeat("─", 50) + "\n\n"
 			return ConfigViewMsg{
-				Output:   fmt.Sprintf("Site Configuration: %s\n\nPath: %s\n\n%s", siteName, path, string(content)),
-				Path:     path,
-				Type:     "site",
-				SiteName: siteName,
+				Header:    header + summary + separator,
+				Config:    string(content),
+				Path:      path,
+				Type:      "site",
+				SiteName:  siteName,
+				Positions: append(positions, filePositions(separator[1:], path, string(content))...),
 			}
 		}
 	}
 
-	return OutputMsg{Output: fmt.Sprintf("Could not locate configuration file for site: %s\n\nSearched in:\n- /etc/nginx/sites-available/\n- /etc/nginx/sites-enabled/\n- C:\\nginx\\conf\\sites-available\\", siteName)}
+	return OutputMsg{Output: fmt.Sprintf("Could not locate configuration file for site: %s\n\nSearched in:\n%s", siteName, describeSiteDirs())}
 }
 
-func LoadReverseProxies(m ModelInterface) tea.Cmd {
-	return func() tea.Msg {
-		var proxies []string
-
-		// Try to find nginx config and parse for proxy_pass directives
-		configPaths := []string{
-			"/etc/nginx/nginx.conf",
-			"C:\\nginx\\conf\\nginx.conf",
-			"/usr/local/nginx/conf/nginx.conf",
+// summarizeSite renders the server blocks of a site file: listen ports,
+// names, root, TLS certificates and what each location does. It also
+// returns the line of the file each line of the summary describes.
+func summarizeSite(path string, content string) (string, []int) {
+	conf, err := nginxconf.Parse(path, content)
+	if err != nil {
+		line := 0
+		if parseErr, ok := err.(*nginxconf.ParseError); ok {
+			line = parseErr.Line
 		}
+		return "⚠️  Syntax error: " + err.Error(), []int{line}
+	}
+
+	servers := nginxconf.Servers(conf.Directives)
+	if len(servers) == 0 {
+		return "No server blocks defined in this file", []int{0}
+	}
 
-		// Also check sites-available and sites-enabled directories
-		siteDirs := []string{
-			"/etc/nginx/sites-available",
-			"/etc/nginx/sites-enabled",
-			"/etc/nginx/conf.d",
-			"C:\\nginx\\conf\\sites-available",
-			"/usr/local/nginx/sites-available",
+	orNone := func(values []string) string {
+		if len(values) == 0 {
+			return "-"
 		}
+		return strings.Join(values, ", ")
+	}
 
-		// Parse config files for proxy_pass directives
-		var allConfigs []string
+	var lines []string
+	var fileLines []int
+	add := func(fileLine int, format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+		fileLines = append(fileLines, fileLine)
+	}
 
-		// Add main config
-		for _, path := range configPaths {
-			if _, err := os.Stat(path); err == nil {
-				allConfigs = append(allConfigs, path)
-			}
+	for i, server := range servers {
+		if i > 0 {
+			add(0, "")
+		}
+		add(server.Line, "Server #%d (line %d)", i+1, server.Line)
+		add(server.Line, "  Listen:       %s", orNone(server.Listen))
+		add(server.Line, "  Server names: %s", orNone(server.ServerNames))
+		if server.Root != "" {
+			add(server.Line, "  Root:         %s", server.Root)
+		}
+		if server.Certificate != "" {
+			add(server.Line, "  Certificate:  %s", server.Certificate)
+		}
+		if server.CertificateKey != "" {
+			add(server.Line, "  Cert key:     %s", server.CertificateKey)
+		}
+		for _, action := range server.Actions {
+			add(action.Line, "  Action:       %s", action.String())
 		}
 
-		// Add site configs
-		for _, dir := range siteDirs {
-			entries, err := os.ReadDir(dir)
-			if err == nil {
-				for _, entry := range entries {
-					if !entry.IsDir() {
-						allConfigs = append(allConfigs, dir+"/"+entry.Name())
-					}
-				}
+		if len(server.Locations) == 0 {
+			continue
+		}
+
+		// Align the actions after the longest location match
+		width := 0
+		for _, location := range server.Locations {
+			if len(location.Match) > width {
+				width = len(location.Match)
 			}
 		}
 
-		// Parse configs for proxy_pass
-		proxyMap := make(map[string]bool)
-		for _, configPath := range allConfigs {
-			content, err := os.ReadFile(configPath)
-			if err == nil {
-				lines := strings.Split(string(content), "\n")
-				for _, line := range lines {
-					trimmed := strings.TrimSpace(line)
-					if strings.Contains(trimmed, "proxy_pass") {
-						// Extract the proxy target
-						parts := strings.Fields(trimmed)
-						for i, part := range parts {
-							if part == "proxy_pass" && i+1 < len(parts) {
-								target := strings.TrimRight(parts[i+1], ";")
-								if !proxyMap[target] {
-									proxyMap[target] = true
-									proxies = append(proxies, target)
-								}
-							}
-						}
-					}
-				}
+		add(server.Line, "  Locations:")
+		for _, location := range server.Locations {
+			var actions []string
+			for _, action := range location.Actions {
+				actions = append(actions, action.String())
 			}
+			add(location.Line, "    %-*s  %s", width, location.Match, orNone(actions))
 		}
+	}
 
+	return strings.Join(lines, "\n"), fileLines
+}
+
+func LoadReverseProxies(m ModelInterface) tea.Cmd {
+	return func() tea.Msg {
+		proxies := ListReverseProxies()
 		if len(proxies) > 0 {
 			// Prepend "Add Reverse Proxy" to the proxies list
 			m.SetSubMenus(3, append([]string{"Add Reverse Proxy"}, proxies...))
@@ -631,19 +565,139 @@ func LoadReverseProxies(m ModelInterface) tea.Cmd {
 	}
 }
 
+// ListReverseProxies describes every proxy_pass of the effective
+// configuration and of the site directories, e.g. "example.com/api -> http://127.0.0.1:3000"
+func ListReverseProxies() []string {
+	var labels []string
+	for _, proxy := range ReverseProxies() {
+		labels = append(labels, proxy.String())
+	}
+	return labels
+}
+
+// Proxy is a proxy_pass directive and where it sits
+type Proxy struct {
+	Host     string // first server_name of the server block, "" for a catch-all
+	Location string // path of the enclosing location
+	Target   string // proxy_pass argument
+	File     string
+	Line     int
+}
+
+// String returns the label of the proxy, e.g. "example.com/api -> http://127.0.0.1:3000"
+func (p Proxy) String() string {
+	if p.Host == "" && p.Location == "" {
+		return p.Target
+	}
+	return p.Host + p.Location + " -> " + p.Target
+}
+
+// ReverseProxies returns every proxy_pass of the effective configuration
+// and of the site directories, each once
+func ReverseProxies() []Proxy {
+	var proxies []Proxy
+
+	// Parse the effective config plus every file of the site directories,
+	// so proxies of disabled sites are listed too
+	var allConfigs []string
+	if path, err := FindNginxConfigPath(); err == nil {
+		if tree, err := nginxconf.Resolve(path, discovery.Get().ConfPrefix); err == nil {
+			for _, conf := range tree.Files {
+				allConfigs = append(allConfigs, conf.File)
+			}
+		}
+	}
+
+	for _, dir := range discovery.Get().SiteDirs() {
+		entries, err := os.ReadDir(dir)
+		if err == nil {
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					allConfigs = append(allConfigs, filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+	}
+
+	// Parse configs for proxy_pass directives (comments are skipped by the parser)
+	proxyMap := make(map[string]bool)
+	for _, configPath := range allConfigs {
+		conf, err := nginxconf.ParseFile(configPath)
+		if err != nil {
+			continue
+		}
+		nginxconf.Walk(conf.Directives, func(d *nginxconf.Directive, parents []*nginxconf.Directive) bool {
+			if d.Name != "proxy_pass" || len(d.Args) == 0 {
+				return true
+			}
+			proxy := newProxy(d, parents)
+			if !proxyMap[proxy.String()] {
+				proxyMap[proxy.String()] = true
+				proxies = append(proxies, proxy)
+			}
+			return true
+		})
+	}
+	return proxies
+}
+
+// describeSiteDirs lists the discovered site directories for "not found" messages
+func describeSiteDirs() string {
+	dirs := discovery.Get().SiteDirs()
+	if len(dirs) == 0 {
+		return "- (no sites-available, sites-enabled or conf.d directory found)\n\n" + describePaths()
+	}
+	return "- " + strings.Join(dirs, "\n- ")
+}
+
+// describeProxy builds the submenu label for a proxy_pass directive,
+// e.g. "example.com/api -> http://127.0.0.1:3000"
+func describeProxy(d *nginxconf.Directive, parents []*nginxconf.Directive) string {
+	return newProxy(d, parents).String()
+}
+
+// newProxy describes a *_pass directive from its enclosing blocks
+func newProxy(d *nginxconf.Directive, parents []*nginxconf.Directive) Proxy {
+	proxy := Proxy{Target: d.Arg(0), File: d.File, Line: d.Line}
+	for _, parent := range parents {
+		switch parent.Name {
+		case "server":
+			if names := parent.Children("server_name"); len(names) > 0 && names[0].Arg(0) != "_" {
+				proxy.Host = names[0].Arg(0)
+			}
+		case "location":
+			// The path is the last argument (modifiers like "=" or "~" come first)
+			if len(parent.Args) > 0 {
+				proxy.Location = parent.Args[len(parent.Args)-1]
+			}
+		}
+	}
+	return proxy
+}
+
+// hasServerBlock reports whether a file defines a server block.
+// Files that fail to parse are reported as sites so broken configs stay visible.
+func hasServerBlock(path string) bool {
+	conf, err := nginxconf.ParseFile(path)
+	if err != nil {
+		return true
+	}
+	return len(nginxconf.Find(conf.Directives, "server")) > 0
+}
+
 func AddSite(siteType string, siteName string) tea.Msg {
+	// The name becomes a file name, often written as root
+	if err := checkSiteName(siteName); err != nil {
+		return OutputMsg{Output: "Invalid site name: " + err.Error()}
+	}
+
 	var configContent string
-	var actualSiteName string
 
 	if siteType == "Laravel" {
-		actualSiteName = siteName
-		if actualSiteName == "" {
-			actualSiteName = "laravel-site"
-		}
 		configContent = fmt.Sprintf(`server {
     listen 80;
     server_name %s.local;
-    root /var/www/%s/public;
+    root %s/public;
 
     add_header X-Frame-Options "SAMEORIGIN";
     add_header X-Content-Type-Options "nosniff";
@@ -662,7 +716,7 @@ func AddSite(siteType string, siteName string) tea.Msg {
     error_page 404 /index.php;
 
     location ~ \.php$ {
-        fastcgi_pass unix:/var/run/php/php8.1-fpm.sock;
+        fastcgi_pass %s;
         fastcgi_param SCRIPT_FILENAME $realpath_root$fastcgi_script_name;
         include fastcgi_params;
     }
@@ -670,16 +724,12 @@ func AddSite(siteType string, siteName string) tea.Msg {
     location ~ /\.(?!well-known).* {
         deny all;
     }
-}`, actualSiteName, actualSiteName)
+}`, siteName, siteRoot(siteName), config.Get().PHPFPMSocket)
 	} else if siteType == "Static" {
-		actualSiteName = siteName
-		if actualSiteName == "" {
-			actualSiteName = "static-site"
-		}
 		configContent = fmt.Sprintf(`server {
     listen 80;
     server_name %s.local;
-    root /var/www/%s;
+    root %s;
 
     index index.html index.htm;
 
@@ -689,16 +739,12 @@ func AddSite(siteType string, siteName string) tea.Msg {
 
     location = /favicon.ico { access_log off; log_not_found off; }
     location = /robots.txt  { access_log off; log_not_found off; }
-}`, actualSiteName, actualSiteName)
+}`, siteName, siteRoot(siteName))
 	} else if siteType == "VanillaPHP" {
-		actualSiteName = siteName
-		if actualSiteName == "" {
-			actualSiteName = "php-site"
-		}
 		configContent = fmt.Sprintf(`server {
     listen 80;
     server_name %s.local;
-    root /var/www/%s;
+    root %s;
 
     index index.php index.html index.htm;
 
@@ -710,55 +756,76 @@ func AddSite(siteType string, siteName string) tea.Msg {
     location = /robots.txt  { access_log off; log_not_found off; }
 
     location ~ \.php$ {
-        fastcgi_pass unix:/var/run/php/php8.1-fpm.sock;
+        fastcgi_pass %s;
         fastcgi_param SCRIPT_FILENAME $realpath_root$fastcgi_script_name;
         include fastcgi_params;
     }
-}`, actualSiteName, actualSiteName)
+}`, siteName, siteRoot(siteName), config.Get().PHPFPMSocket)
 	} else {
-		actualSiteName = siteName
-		if actualSiteName == "" {
-			actualSiteName = "custom-site"
-		}
 		configContent = fmt.Sprintf(`server {
     listen 80;
     server_name %s.local;
-    root /var/www/%s;
+    root %s;
 
     index index.html index.htm index.php;
 
     location / {
         try_files $uri $uri/ =404;
     }
-}`, actualSiteName, actualSiteName)
+}`, siteName, siteRoot(siteName))
 	}
 
-	// Try to write to sites-available
-	sitePaths := []string{
-		"/etc/nginx/sites-available/" + actualSiteName,
-		"C:\\nginx\\conf\\sites-available\\" + actualSiteName,
-		"/usr/local/nginx/sites-available/" + actualSiteName,
+	// Write to sites-available (or conf.d on layouts without it)
+	paths := discovery.Get()
+	dir := paths.WritableSiteDir()
+	if dir == "" {
+		return OutputMsg{Output: "Could not locate nginx sites directory.\n\nPlease ensure Nginx is properly installed.\n\n" + describePaths()}
 	}
 
-	for _, path := range sitePaths {
-		dir := path[:strings.LastIndex(path, string(os.PathSeparator))]
+	path := siteFilePath(dir, siteName)
+	enabledPath := ""
+	if dir == paths.SitesAvailable && paths.SitesEnabled != "" {
+		enabledPath = filepath.Join(paths.SitesEnabled, filepath.Base(path))
+	}
 
-		// Check if directory exists
-		if _, err := os.Stat(dir); err == nil {
-			// Try to write the file
-			err := os.WriteFile(path, []byte(configContent), 0644)
-			if err == nil {
-				// Try to create symlink to sites-enabled
-				enabledPath := strings.Replace(path, "sites-available", "sites-enabled", 1)
-				os.Symlink(path, enabledPath)
+	// Nothing is written until the user accepts the preview
+	return previewWrite(PendingWrite{
+		Kind:        "site",
+		Name:        siteName,
+		Path:        path,
+		EnabledPath: enabledPath,
+		Content:     configContent,
+		Change:      Change{Action: "create", Kind: "site", Name: siteName, Path: path, Type: siteType, Root: siteRoot(siteName)},
+	})
+}
 
-				return OutputMsg{Output: fmt.Sprintf("Site '%s' created successfully!\n\nConfiguration file: %s\n\nType: %s\n\nNext steps:\n1. Create directory: /var/www/%s\n2. Reload nginx: sudo systemctl reload nginx\n3. Add to /etc/hosts: 127.0.0.1 %s.local", actualSiteName, path, siteType, actualSiteName, actualSiteName)}
-			}
-			return OutputMsg{Output: fmt.Sprintf("Failed to create site: %s\n\nYou may need sudo/administrator privileges", err.Error())}
-		}
-	}
+// siteRoot returns the document root of a new site under the configured web root
+func siteRoot(siteName string) string {
+	return strings.TrimRight(config.Get().WebRoot, "/") + "/" + siteName
+}
+
+// checkSiteName rejects a site name that isn't a plain file name, so the
+// site file can't land outside the sites directory
+func checkSiteName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("the name is empty")
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%q contains a path separator", name)
+	case strings.Contains(name, ".."):
+		return fmt.Errorf("%q contains \"..\"", name)
+	case strings.HasPrefix(name, "-"):
+		return fmt.Errorf("%q starts with \"-\"", name)
+	}
+	return nil
+}
 
-	return OutputMsg{Output: "Could not locate nginx sites directory.\n\nPlease ensure Nginx is properly installed.\n\nCommon locations:\n- /etc/nginx/sites-available/ (Linux)\n- C:\\nginx\\conf\\sites-available\\ (Windows)"}
+// siteFilePath returns where a new site file goes. conf.d only loads *.conf files.
+func siteFilePath(dir string, name string) string {
+	if filepath.Base(dir) == "conf.d" && !strings.HasSuffix(name, ".conf") {
+		name += ".conf"
+	}
+	return filepath.Join(dir, name)
 }
 
 func AddProxy(proxyType string, proxyConfig string) tea.Msg {
@@ -858,79 +925,87 @@ server {
 }`, location, upstreamBlock, location, upstreamName)
 	}
 
-	// Try to write to sites-available or conf.d
-	configPaths := []string{
-		"/etc/nginx/sites-available/" + configName,
-		"/etc/nginx/conf.d/" + configName + ".conf",
-		"C:\\nginx\\conf\\sites-available\\" + configName,
-		"/usr/local/nginx/sites-available/" + configName,
+	// Write to sites-available or conf.d
+	paths := discovery.Get()
+	dir := paths.WritableSiteDir()
+	if dir == "" {
+		return OutputMsg{Output: "Could not locate nginx configuration directory.\n\nPlease ensure Nginx is properly installed.\n\n" + describePaths()}
 	}
 
-	for _, path := range configPaths {
-		dir := path[:strings.LastIndex(path, string(os.PathSeparator))]
-
-		// Check if directory exists
-		if _, err := os.Stat(dir); err == nil {
-			// Try to write the file
-			err := os.WriteFile(path, []byte(configContent), 0644)
-			if err == nil {
-				// Try to create symlink to sites-enabled (if applicable)
-				if strings.Contains(path, "sites-available") {
-					enabledPath := strings.Replace(path, "sites-available", "sites-enabled", 1)
-					os.Symlink(path, enabledPath)
-				}
-
-				return OutputMsg{Output: fmt.Sprintf("Reverse proxy '%s' created successfully!\n\nConfiguration file: %s\n\nType: %s\n\nLocation: %s\nBackend(s): %s\n\nNext steps:\n1. Test configuration: sudo nginx -t\n2. Reload nginx: sudo systemctl reload nginx\n\nNote: This creates a catch-all server block (server_name _).\nFor production, edit the config to set a specific server_name.", configName, path, proxyType, location, backends)}
-			}
-			return OutputMsg{Output: fmt.Sprintf("Failed to create reverse proxy: %s\n\nYou may need sudo/administrator privileges", err.Error())}
-		}
+	path := siteFilePath(dir, configName)
+	enabledPath := ""
+	if dir == paths.SitesAvailable && paths.SitesEnabled != "" {
+		enabledPath = filepath.Join(paths.SitesEnabled, filepath.Base(path))
 	}
 
-	return OutputMsg{Output: "Could not locate nginx configuration directory.\n\nPlease ensure Nginx is properly installed.\n\nCommon locations:\n- /etc/nginx/sites-available/ (Linux)\n- /etc/nginx/conf.d/ (Linux)\n- C:\\nginx\\conf\\sites-available\\ (Windows)"}
+	// Nothing is written until the user accepts the preview
+	return previewWrite(PendingWrite{
+		Kind:        "reverse proxy",
+		Name:        configName,
+		Path:        path,
+		EnabledPath: enabledPath,
+		Content:     configContent,
+		Change:      Change{Action: "create", Kind: "reverse proxy", Name: configName, Path: path, Type: proxyType, Location: location, Backends: backends},
+	})
 }
 
 func DeleteSite(siteName string) tea.Msg {
 	if siteName == "" || siteName == "Add site" || siteName == "Loading sites..." || siteName == "No sites found" {
 		return OutputMsg{Output: "Invalid site name"}
 	}
-
-	// Try to find and delete the site config file
-	sitePaths := []string{
-		"/etc/nginx/sites-available/" + siteName,
-		"C:\\nginx\\conf\\sites-available\\" + siteName,
-		"/usr/local/nginx/sites-available/" + siteName,
+	if err := checkSiteName(siteName); err != nil {
+		return OutputMsg{Output: "Invalid site name: " + err.Error()}
 	}
 
+	// Find the site config file; prefer the real file over a sites-enabled symlink
+	paths := discovery.Get()
 	var configPath string
 	var foundPath bool
 
-	// Find the config file
-	for _, path := range sitePaths {
-		if _, err := os.Stat(path); err == nil {
-			configPath = path
-			foundPath = true
+	for _, dir := range paths.SiteDirs() {
+		for _, path := range []string{filepath.Join(dir, siteName), filepath.Join(dir, siteName+disabledSuffix)} {
+			if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink == 0 {
+				configPath = path
+				foundPath = true
+				break
+			}
+		}
+		if foundPath {
 			break
 		}
 	}
 
 	if !foundPath {
-		return OutputMsg{Output: fmt.Sprintf("Could not locate configuration file for site: %s\n\nSearched in:\n- /etc/nginx/sites-available/\n- C:\\nginx\\conf\\sites-available\\", siteName)}
+		return OutputMsg{Output: fmt.Sprintf("Could not locate configuration file for site: %s\n\nSearched in:\n%s", siteName, describeSiteDirs())}
+	}
+
+	enabledPath := ""
+	if paths.SitesEnabled != "" && filepath.Dir(configPath) != paths.SitesEnabled {
+		enabledPath = filepath.Join(paths.SitesEnabled, siteName)
+	}
+
+	tx, err := BeginTransaction(fmt.Sprintf("delete site %s", siteName), configPath, enabledPath)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to read site configuration: %s\n\nYou may need sudo/administrator privileges", err.Error())}
 	}
 
 	// Try to remove symlink from sites-enabled first
-	enabledPath := strings.Replace(configPath, "sites-available", "sites-enabled", 1)
-	if _, err := os.Stat(enabledPath); err == nil {
-		err := os.Remove(enabledPath)
-		if err != nil {
-			return OutputMsg{Output: fmt.Sprintf("Failed to remove symlink from sites-enabled: %s\n\nYou may need sudo/administrator privileges", err.Error())}
+	if enabledPath != "" {
+		if _, err := os.Lstat(enabledPath); err == nil {
+			err := os.Remove(enabledPath)
+			if err != nil {
+				return OutputMsg{Output: fmt.Sprintf("Failed to remove symlink from sites-enabled: %s\n\nYou may need sudo/administrator privileges", err.Error())}
+			}
 		}
 	}
 
 	// Delete the config file
-	err := os.Remove(configPath)
+	err = os.Remove(configPath)
 	if err != nil {
+		// Put the symlink back so the site isn't left half-deleted
+		tx.Rollback()
 		return OutputMsg{Output: fmt.Sprintf("Failed to delete site configuration: %s\n\nYou may need sudo/administrator privileges", err.Error())}
 	}
 
-	return OutputMsg{Output: fmt.Sprintf("Site '%s' deleted successfully!\n\nRemoved: %s\n\nNext steps:\n1. Reload nginx: sudo systemctl reload nginx\n2. Remove site directory if needed: /var/www/%s", siteName, configPath, siteName)}
+	return tx.Validate(Change{Action: "delete", Kind: "site", Name: siteName, Path: configPath, Root: siteRoot(siteName)})
 }
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// EditFileMsg carries a file to open in the built-in editor
+type EditFileMsg struct {
+	Path    string
+	Line    int // line to put the cursor on, 0 for the top
+	Content string
+	Err     error
+}
+
+// EditSavedMsg reports a save from the built-in editor
+type EditSavedMsg struct {
+	Path    string
+	Content string // text written
+	Err     error  // the file could not be written; it is unchanged
+	Change  Change // the edit with its nginx -t result
+}
+
+// OpenEdit reads a file for the built-in editor
+func OpenEdit(path string, line int) EditFileMsg {
+	content, err := os.ReadFile(path)
+	return EditFileMsg{Path: path, Line: line, Content: string(content), Err: err}
+}
+
+// SaveEdit writes the text of the built-in editor to path and tests it with
+// nginx -t, like an edit made in an external editor. When nginx rejects it
+// the file is rolled back; the editor still holds the text, so nothing is
+// kept aside.
+func SaveEdit(path string, content string) EditSavedMsg {
+	tx, err := BeginTransaction("edit "+filepath.Base(path), path)
+	if err != nil {
+		return EditSavedMsg{Path: path, Content: content, Err: err}
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		tx.DiscardBackup()
+		return EditSavedMsg{Path: path, Content: content, Err: err}
+	}
+
+	msg := tx.Validate(Change{Action: "edit", Kind: "file", Name: filepath.Base(path), Path: path})
+	return EditSavedMsg{Path: path, Content: content, Change: msg.Change}
+}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/nginxconf"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ErrorLogMsg carries the parsed tail, or a time span, of the error log
+type ErrorLogMsg struct {
+	Path      string
+	Lines     int       // lines requested from the end of the log, or read from the span
+	Span      logs.Span // time span shown, empty for the end of the log
+	Truncated bool      // the span has more lines than were read
+	Entries   []*logs.ErrorEntry
+	Skipped   int // lines that don't start an entry, e.g. continuations
+
+	Site      string // site the log was opened for, "" for the global log
+	Inherited bool   // the site sets no error_log and writes to the global one
+}
+
+func ViewErrorLogs() tea.Msg {
+	return ViewErrorLogFile(discovery.Get().ErrorLog)
+}
+
+// ViewErrorLogFile shows the end of an error log, or of one of its rotated copies
+func ViewErrorLogFile(path string) tea.Msg {
+	lines := config.Get().TailLines
+	output, ok := TailFile(path, lines)
+	if !ok {
+		return OutputMsg{Output: "Could not locate nginx error log file.\n\n" + describePaths()}
+	}
+
+	msg := ErrorLogMsg{Path: path, Lines: lines}
+	msg.Entries, msg.Skipped = parseErrorLog(strings.Split(output, "\n"))
+	return msg
+}
+
+// ViewErrorLogSpan shows the entries of an error log, or of one of its
+// rotated copies, that were written in a time span
+func ViewErrorLogSpan(path string, span logs.Span) tea.Msg {
+	lines, truncated, err := readSpan(path, span, func(line string) (time.Time, bool) {
+		entry, ok := logs.ParseErrorLine(line)
+		if !ok {
+			return time.Time{}, false
+		}
+		return entry.Time, true
+	})
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to read %s: %s\n\nYou may need sudo/administrator privileges", path, err.Error())}
+	}
+
+	msg := ErrorLogMsg{Path: path, Lines: len(lines), Span: span, Truncated: truncated}
+	msg.Entries, msg.Skipped = parseErrorLog(lines)
+	return msg
+}
+
+// parseErrorLog parses error log lines. Lines that don't start an entry are
+// counted as skipped.
+func parseErrorLog(lines []string) (entries []*logs.ErrorEntry, skipped int) {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if entry, ok := logs.ParseErrorLine(line); ok {
+			entries = append(entries, entry)
+		} else {
+			skipped++
+		}
+	}
+	return entries, skipped
+}
+
+// passDirectives are the directives that hand a request to an upstream
+var passDirectives = map[string]bool{
+	"proxy_pass":   true,
+	"fastcgi_pass": true,
+	"uwsgi_pass":   true,
+	"scgi_pass":    true,
+	"grpc_pass":    true,
+}
+
+// ViewUpstreamConfig opens the configuration that passes requests to the
+// upstream of an error log entry, scrolled to the matching *_pass directive.
+// When several directives point at the upstream, the one in the server
+// block named in the entry wins.
+func ViewUpstreamConfig(entry *logs.ErrorEntry) tea.Msg {
+	if entry.Upstream == "" {
+		return OutputMsg{Output: "This error doesn't name an upstream.\n\n" + entry.Raw}
+	}
+
+	path, err := FindNginxConfigPath()
+	if err != nil {
+		return OutputMsg{Output: "Could not locate nginx configuration file.\n\n" + describePaths()}
+	}
+	tree, err := nginxconf.Resolve(path, discovery.Get().ConfPrefix)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to parse %s: %s", path, err.Error())}
+	}
+
+	address := upstreamAddress(entry.Upstream)
+	upstreams := upstreamServers(tree.Directives)
+
+	var match *nginxconf.Directive
+	var matchParents []*nginxconf.Directive
+	matchScore := 0
+	nginxconf.Walk(tree.Directives, func(d *nginxconf.Directive, parents []*nginxconf.Directive) bool {
+		if !passDirectives[d.Name] || !passesTo(d.Arg(0), address, upstreams) {
+			return true
+		}
+		score := 1
+		if entry.Server != "" && serverNamed(parents, entry.Server) {
+			score = 2
+		}
+		if score > matchScore {
+			match, matchParents, matchScore = d, parents, score
+		}
+		return true
+	})
+
+	if match == nil {
+		return OutputMsg{Output: fmt.Sprintf("No proxy_pass, fastcgi_pass, uwsgi_pass, scgi_pass or grpc_pass in the configuration points at %s.\n\n%s", entry.Upstream, entry.Raw)}
+	}
+
+	header := fmt.Sprintf("Upstream error: %s\nUpstream: %s\nServer: %s\n\n%s %s in %s:%d\n(%s)\n%s\n",
+		entry.Message, entry.Upstream, entry.Server,
+		match.Name, match.Arg(0), match.File, match.Line, describeProxy(match, matchParents),
+		strings.Repeat("─", 50))
+	return viewFileAt(match.File, match.Line, header)
+}
+
+// viewFileAt shows a file under header, pointing at line and scrolling so
+// a few lines of context stay above it
+func viewFileAt(path string, line int, header string) tea.Msg {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to read %s: %s", path, err.Error())}
+	}
+	headerLines := strings.Count(header, "\n")
+
+	msg := ConfigViewMsg{
+		Header:    header,
+		Config:    string(content),
+		Path:      path,
+		Line:      line,
+		ScrollTo:  max(headerLines+line-1-3, 0),
+		Positions: filePositions(header, path, string(content)),
+	}
+	// Files of the site directories reload as sites after editing
+	site := filepath.Base(path)
+	if sitePath, err := FindSiteConfigPath(site); err == nil && sameFile(sitePath, path) {
+		msg.Type = "site"
+		msg.SiteName = site
+	}
+	return msg
+}
+
+// sameFile reports whether two paths lead to the same file, following symlinks
+func sameFile(a string, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// upstreamAddress returns the host:port or unix:path an upstream URL from
+// the error log points at, e.g. "http://127.0.0.1:3000/api" -> "127.0.0.1:3000"
+func upstreamAddress(upstream string) string {
+	scheme, rest, found := strings.Cut(upstream, "://")
+	if !found {
+		scheme, rest = "", upstream
+	}
+	if strings.HasPrefix(rest, "unix:") {
+		// unix:/run/app.sock:/uri
+		socket, _, _ := strings.Cut(strings.TrimPrefix(rest, "unix:"), ":")
+		return "unix:" + socket
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	return normalizeAddress(host, defaultPort(scheme))
+}
+
+// normalizeAddress adds the default port and treats localhost as 127.0.0.1,
+// which is what nginx logs after resolving it
+func normalizeAddress(address string, port string) string {
+	if strings.HasPrefix(address, "unix:") {
+		return address
+	}
+	host, p := address, port
+	if i := strings.LastIndex(address, ":"); i >= 0 && !strings.HasSuffix(address, "]") {
+		host, p = address[:i], address[i+1:]
+	}
+	if host == "localhost" {
+		host = "127.0.0.1"
+	}
+	return host + ":" + p
+}
+
+func defaultPort(scheme string) string {
+	if scheme == "https" || scheme == "grpcs" {
+		return "443"
+	}
+	return "80"
+}
+
+// upstreamServers maps each upstream block name to the addresses of its servers
+func upstreamServers(directives []*nginxconf.Directive) map[string][]string {
+	servers := map[string][]string{}
+	for _, u := range nginxconf.Find(directives, "upstream") {
+		for _, s := range u.Children("server") {
+			servers[u.Arg(0)] = append(servers[u.Arg(0)], normalizeAddress(s.Arg(0), "80"))
+		}
+	}
+	return servers
+}
+
+// passesTo reports whether a *_pass target reaches address, directly
+// or through an upstream block. Targets built from variables never match.
+func passesTo(target string, address string, upstreams map[string][]string) bool {
+	if target == "" || strings.Contains(target, "$") {
+		return false
+	}
+
+	scheme, rest, found := strings.Cut(target, "://")
+	if !found {
+		scheme, rest = "", target
+	}
+	if strings.HasPrefix(rest, "unix:") {
+		return upstreamAddress(target) == address
+	}
+	host, _, _ := strings.Cut(rest, "/")
+
+	if servers, ok := upstreams[host]; ok {
+		for _, server := range servers {
+			if server == address {
+				return true
+			}
+		}
+		return false
+	}
+	return normalizeAddress(host, defaultPort(scheme)) == address
+}
+
+// serverNamed reports whether the enclosing server block has the given server_name
+func serverNamed(parents []*nginxconf.Directive, name string) bool {
+	for _, parent := range parents {
+		if parent.Name != "server" {
+			continue
+		}
+		for _, names := range parent.Children("server_name") {
+			for _, n := range names.Args {
+				if n == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/logs"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FollowBufferLines is how many lines a followed log keeps in memory
+const FollowBufferLines = 5000
+
+// FollowStartedMsg is returned when following a log begins. Lines holds the
+// last lines of the file, new lines arrive as LogLinesMsg.
+type FollowStartedMsg struct {
+	Follower *logs.Follower
+	Title    string
+	Lines    []string
+}
+
+// LogLinesMsg carries lines appended to a followed log
+type LogLinesMsg struct {
+	Follower *logs.Follower
+	Lines    []string
+	Err      error
+}
+
+// FollowErrorLog starts following the error log
+func FollowErrorLog() tea.Msg {
+	return FollowLog("error log", discovery.Get().ErrorLog, config.Get().TailLines)
+}
+
+// FollowAccessLog starts following the access log
+func FollowAccessLog() tea.Msg {
+	return FollowLog("access log", discovery.Get().AccessLog, config.Get().TailLines)
+}
+
+// FollowLog starts following the log at path, starting with its last
+// backlog lines; name describes it in the title
+func FollowLog(name string, path string, backlog int) tea.Msg {
+	if path == "" {
+		return OutputMsg{Output: fmt.Sprintf("Could not locate nginx %s file.\n\n%s", name, describePaths())}
+	}
+
+	follower, lines, err := logs.Follow(path, backlog)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to follow %s: %s\n\nYou may need sudo/administrator privileges", path, err.Error())}
+	}
+	return FollowStartedMsg{
+		Follower: follower,
+		Title:    fmt.Sprintf("Following %s (%s)", name, path),
+		Lines:    lines,
+	}
+}
+
+// WaitForLogLines waits for the next lines of a followed log. The model
+// issues it again after every LogLinesMsg until the follow is stopped.
+func WaitForLogLines(follower *logs.Follower) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := follower.Next()
+		return LogLinesMsg{Follower: follower, Lines: lines, Err: err}
+	}
+}
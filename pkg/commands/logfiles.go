@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/logs"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LogFilesMsg lists a log and its rotated copies, newest first
+type LogFilesMsg struct {
+	Kind  string // "error" or "access"
+	Files []logs.LogFile
+}
+
+// LogPath returns the global "error" or "access" log, "" when none was found
+func LogPath(kind string) string {
+	if kind == "error" {
+		return discovery.Get().ErrorLog
+	}
+	return discovery.Get().AccessLog
+}
+
+// ListLogFiles finds the rotated copies of the error or access log
+func ListLogFiles(kind string) tea.Msg {
+	path := LogPath(kind)
+	if path == "" {
+		return OutputMsg{Output: fmt.Sprintf("Could not locate nginx %s log file.\n\n%s", kind, describePaths())}
+	}
+
+	files, err := logs.Rotated(path)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to list %s: %s\n\nYou may need sudo/administrator privileges", path, err.Error())}
+	}
+	if len(files) == 0 {
+		return OutputMsg{Output: fmt.Sprintf("No %s log files found next to %s", kind, path)}
+	}
+	return LogFilesMsg{Kind: kind, Files: files}
+}
+
+// spanMaxLines bounds how many lines of a time span are shown
+const spanMaxLines = 10000
+
+// readSpan reads a whole log, rotated or gzipped, and returns its lines
+// whose time falls in span, as read by timeOf. Lines without a time, like
+// the continuations of an error, go with the line before them. truncated is
+// set when the span has more than spanMaxLines lines.
+func readSpan(path string, span logs.Span, timeOf func(line string) (time.Time, bool)) (lines []string, truncated bool, err error) {
+	reader, err := logs.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	in := false
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if t, ok := timeOf(line); ok {
+			in = span.Contains(t)
+		}
+		if !in {
+			continue
+		}
+		if len(lines) == spanMaxLines {
+			return lines, true, nil
+		}
+		lines = append(lines, line)
+	}
+	return lines, false, scanner.Err()
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/diff"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PendingWrite is a generated config file waiting for the user to accept it
+type PendingWrite struct {
+	Kind        string // "site" or "reverse proxy", used in messages
+	Name        string
+	Path        string
+	EnabledPath string // sites-enabled symlink to create, empty on conf.d layouts
+	Content     string
+	Exists      bool   // Path already exists and would be overwritten
+	Change      Change // reported once the file is written
+}
+
+// PreviewMsg asks the user to confirm a PendingWrite. Preview holds the
+// generated file, or a unified diff against the existing file.
+type PreviewMsg struct {
+	Write   PendingWrite
+	Preview string
+}
+
+// previewWrite builds the PreviewMsg for a generated file
+func previewWrite(w PendingWrite) tea.Msg {
+	current, err := os.ReadFile(w.Path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return OutputMsg{Output: fmt.Sprintf("Failed to read %s: %s\n\nYou may need sudo/administrator privileges", w.Path, err.Error())}
+		}
+		return PreviewMsg{Write: w, Preview: w.Content}
+	}
+
+	w.Exists = true
+	unified := diff.Unified(w.Path+" (current)", w.Path+" (generated)", string(current), w.Content, 3)
+	if unified == "" {
+		unified = "The generated file is identical to the existing one."
+	}
+	return PreviewMsg{Write: w, Preview: unified}
+}
+
+// WriteConfig writes an accepted PendingWrite, links it into sites-enabled
+// and tests the configuration
+func WriteConfig(w PendingWrite) tea.Msg {
+	reason := fmt.Sprintf("add %s %s", w.Kind, w.Name)
+	if w.Exists {
+		reason = fmt.Sprintf("overwrite %s %s", w.Kind, w.Name)
+	}
+
+	tx, err := BeginTransaction(reason, w.Path, w.EnabledPath)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to create %s: %s\n\nYou may need sudo/administrator privileges", w.Kind, err.Error())}
+	}
+
+	err = os.WriteFile(w.Path, []byte(w.Content), 0644)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to create %s: %s\n\nYou may need sudo/administrator privileges", w.Kind, err.Error())}
+	}
+
+	// Try to create symlink to sites-enabled (if applicable)
+	if w.EnabledPath != "" {
+		os.Symlink(w.Path, w.EnabledPath)
+	}
+
+	change := w.Change
+	if w.Exists {
+		change.Action = "overwrite"
+	}
+	return tx.Validate(change)
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ServiceMsg carries the outcome of starting, stopping, restarting or reloading nginx
+type ServiceMsg struct {
+	Result ServiceResult
+}
+
+// ServiceResult is the outcome of a service action. The methods are tried
+// in turn until one works; the failed ones are kept to explain a failure.
+type ServiceResult struct {
+	Action   string // "start", "stop", "restart" or "reload" as requested
+	Done     string // what the working method did; a restart may fall back to "reload", a stop to "kill"
+	OK       bool
+	Method   string // the method that worked
+	Output   string // its output
+	Attempts []Attempt
+}
+
+// Attempt is a method that failed, with what it printed
+type Attempt struct {
+	Method string
+	Output string
+}
+
+// serviceMethod is one way of running a service action
+type serviceMethod struct {
+	name string
+	args []string
+	done string // "" when it does the requested action
+}
+
+// runService tries each method until one succeeds
+func runService(action string, methods []serviceMethod) ServiceResult {
+	result := ServiceResult{Action: action}
+	for _, method := range methods {
+		output, err := exec.Command(method.args[0], method.args[1:]...).CombinedOutput()
+		if err == nil {
+			result.OK = true
+			result.Done = action
+			if method.done != "" {
+				result.Done = method.done
+			}
+			result.Method = method.name
+			result.Output = string(output)
+			return result
+		}
+		if len(output) == 0 {
+			output = []byte(err.Error())
+		}
+		result.Attempts = append(result.Attempts, Attempt{Method: method.name, Output: string(output)})
+	}
+	return result
+}
+
+// Start starts nginx through the Windows service, systemd or the binary
+func Start() ServiceResult {
+	return runService("start", []serviceMethod{
+		{name: "Windows (net start)", args: []string{"net", "start", "nginx"}},
+		{name: "Systemd (sudo)", args: []string{"sudo", "systemctl", "start", "nginx"}},
+		{name: "Systemd (no sudo)", args: []string{"systemctl", "start", "nginx"}},
+		{name: "Direct nginx (sudo)", args: []string{"sudo", nginxBinary()}},
+		{name: "Direct nginx (no sudo)", args: []string{nginxBinary()}},
+	})
+}
+
+// Stop stops nginx, killing its processes when nothing else works
+func Stop() ServiceResult {
+	return runService("stop", []serviceMethod{
+		{name: "Windows (net stop)", args: []string{"net", "stop", "nginx"}},
+		{name: "Systemd (sudo)", args: []string{"sudo", "systemctl", "stop", "nginx"}},
+		{name: "Systemd (no sudo)", args: []string{"systemctl", "stop", "nginx"}},
+		{name: "Direct nginx (sudo)", args: []string{"sudo", nginxBinary(), "-s", "stop"}},
+		{name: "Direct nginx (no sudo)", args: []string{nginxBinary(), "-s", "stop"}},
+		{name: "Force kill (sudo pkill)", args: []string{"sudo", "pkill", "-9", "nginx"}, done: "kill"},
+	})
+}
+
+// Restart restarts the nginx service. Without a service manager the
+// running nginx is reloaded instead.
+func Restart() ServiceResult {
+	// Windows has no restart: stop first, the start below brings it back
+	exec.Command("net", "stop", "nginx").Run()
+
+	return runService("restart", []serviceMethod{
+		{name: "Windows (net start)", args: []string{"net", "start", "nginx"}},
+		{name: "Systemd (sudo)", args: []string{"sudo", "systemctl", "restart", "nginx"}},
+		{name: "Systemd (no sudo)", args: []string{"systemctl", "restart", "nginx"}},
+		{name: "Direct nginx reload (sudo)", args: []string{"sudo", nginxBinary(), "-s", "reload"}, done: "reload"},
+		{name: "Direct nginx reload (no sudo)", args: []string{nginxBinary(), "-s", "reload"}, done: "reload"},
+	})
+}
+
+// Reload gracefully reloads nginx, trying the binary, systemd and sudo in turn
+func Reload() ServiceResult {
+	return runService("reload", []serviceMethod{
+		{name: "Direct nginx (no sudo)", args: []string{nginxBinary(), "-s", "reload"}},
+		{name: "Systemd (sudo)", args: []string{"sudo", "systemctl", "reload", "nginx"}},
+		{name: "Direct nginx (sudo)", args: []string{"sudo", nginxBinary(), "-s", "reload"}},
+	})
+}
+
+func StartNginx() tea.Msg {
+	return ServiceMsg{Result: Start()}
+}
+
+func StopNginx() tea.Msg {
+	return ServiceMsg{Result: Stop()}
+}
+
+func RestartNginx() tea.Msg {
+	return ServiceMsg{Result: Restart()}
+}
+
+func ReloadNginx() tea.Msg {
+	return ServiceMsg{Result: Reload()}
+}
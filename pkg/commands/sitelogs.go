@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/nginxconf"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SiteLogPath returns the file a site writes its "access" or "error" log
+// to: the first access_log or error_log of its server blocks, or of one of
+// their locations when the server level sets none. A site that sets none
+// logs to the global file, which is returned with inherited set.
+func SiteLogPath(siteName string, kind string) (path string, inherited bool, err error) {
+	configPath, err := FindSiteConfigPath(siteName)
+	if err != nil {
+		return "", false, err
+	}
+	conf, err := nginxconf.ParseFile(configPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	directive := kind + "_log"
+	paths := discovery.Get()
+	var serverLevel []*nginxconf.Directive
+	for _, server := range nginxconf.Find(conf.Directives, "server") {
+		serverLevel = append(serverLevel, server.Children(directive)...)
+	}
+	for _, d := range serverLevel {
+		if path := paths.LogPath(d.Arg(0)); path != "" {
+			return path, false, nil
+		}
+	}
+	if len(serverLevel) > 0 {
+		// e.g. off or syslog:server=...
+		target := serverLevel[0].Arg(0)
+		if target == "off" {
+			return "", false, fmt.Errorf("the site sets %s off", directive)
+		}
+		return "", false, fmt.Errorf("the site writes %s to %s, which is not a file", directive, target)
+	}
+	// A log of one location still beats the global one
+	for _, d := range nginxconf.Find(conf.Directives, directive) {
+		if path := paths.LogPath(d.Arg(0)); path != "" {
+			return path, false, nil
+		}
+	}
+
+	return LogPath(kind), true, nil
+}
+
+// ViewSiteLog shows the end of a site's "access" or "error" log
+func ViewSiteLog(siteName string, kind string) tea.Msg {
+	path, inherited, err := SiteLogPath(siteName, kind)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Could not read the %s log of site %s: %s", kind, siteName, err.Error())}
+	}
+	if path == "" {
+		return OutputMsg{Output: fmt.Sprintf("Site %s sets no %s_log file and no global one was found.\n\n%s", siteName, kind, describePaths())}
+	}
+
+	msg := ViewAccessLogFile(path)
+	if kind == "error" {
+		msg = ViewErrorLogFile(path)
+	}
+	switch m := msg.(type) {
+	case ErrorLogMsg:
+		m.Site, m.Inherited = siteName, inherited
+		return m
+	case AccessLogMsg:
+		m.Site, m.Inherited = siteName, inherited
+		return m
+	}
+	return msg
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/nginxconf"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// disabledSuffix is appended to conf.d files to stop nginx from loading them
+const disabledSuffix = ".disabled"
+
+// Site is a site of the Sites menu
+type Site struct {
+	Name    string
+	Enabled bool
+	Path    string             // "" when the file could not be found
+	Servers []nginxconf.Server // server blocks of the file
+}
+
+// SiteDetails finds a site's file and summarizes its server blocks
+func SiteDetails(siteName string) Site {
+	site := Site{Name: siteName, Enabled: SiteEnabled(siteName)}
+	path, err := FindSiteConfigPath(siteName)
+	if err != nil {
+		return site
+	}
+	site.Path = path
+	if conf, err := nginxconf.ParseFile(path); err == nil {
+		site.Servers = nginxconf.Servers(conf.Directives)
+	}
+	return site
+}
+
+// SiteEnabled reports whether nginx loads the site: it is linked in
+// sites-enabled, or its conf.d file doesn't carry the .disabled suffix
+func SiteEnabled(siteName string) bool {
+	paths := discovery.Get()
+
+	if paths.SitesEnabled != "" {
+		if _, err := os.Lstat(filepath.Join(paths.SitesEnabled, siteName)); err == nil {
+			return true
+		}
+	}
+	if paths.ConfD != "" {
+		if _, err := os.Stat(filepath.Join(paths.ConfD, siteName)); err == nil {
+			return true
+		}
+		if _, err := os.Stat(filepath.Join(paths.ConfD, siteName+disabledSuffix)); err == nil {
+			return false
+		}
+	}
+	if paths.SitesAvailable != "" {
+		if _, err := os.Stat(filepath.Join(paths.SitesAvailable, siteName)); err == nil {
+			return false
+		}
+	}
+
+	// Found through an include elsewhere, so nginx loads it
+	return true
+}
+
+// ToggleSite disables an enabled site or enables a disabled one, then tests
+// the configuration. On Debian-style layouts the sites-enabled symlink is
+// added or removed, on conf.d layouts the file is renamed to/from .conf.disabled.
+func ToggleSite(siteName string) tea.Msg {
+	if siteName == "" || siteName == "Add site" || siteName == "Loading sites..." || siteName == "No sites found" {
+		return OutputMsg{Output: "Invalid site name"}
+	}
+	if err := checkSiteName(siteName); err != nil {
+		return OutputMsg{Output: "Invalid site name: " + err.Error()}
+	}
+
+	paths := discovery.Get()
+	enabled := SiteEnabled(siteName)
+
+	var action string
+
+	// Paths stay empty for directories this install doesn't have
+	var availablePath, enabledPath, confDPath, disabledPath string
+	if paths.SitesAvailable != "" {
+		availablePath = filepath.Join(paths.SitesAvailable, siteName)
+	}
+	if paths.SitesEnabled != "" {
+		enabledPath = filepath.Join(paths.SitesEnabled, siteName)
+	}
+	if paths.ConfD != "" {
+		confDPath = filepath.Join(paths.ConfD, siteName)
+		disabledPath = confDPath + disabledSuffix
+	}
+
+	// Snapshot every path the toggle may touch so a failing nginx -t can undo it
+	reason := "disable site " + siteName
+	if !enabled {
+		reason = "enable site " + siteName
+	}
+	tx, err := BeginTransaction(reason, availablePath, enabledPath, confDPath, disabledPath)
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to toggle site '%s': %s\n\nYou may need sudo/administrator privileges", siteName, err.Error())}
+	}
+
+	switch {
+	case availablePath != "" && enabledPath != "" && fileExists(availablePath):
+		if enabled {
+			action = "disable"
+			err = removeSymlink(enabledPath)
+		} else {
+			action = "enable"
+			err = os.Symlink(availablePath, enabledPath)
+		}
+	case enabledPath != "" && fileExists(enabledPath):
+		// A plain file in sites-enabled: park it in sites-available
+		if availablePath == "" {
+			return OutputMsg{Output: fmt.Sprintf("Cannot disable '%s': it is a regular file in %s and there is no sites-available directory to move it to", siteName, paths.SitesEnabled)}
+		}
+		action = "disable"
+		err = os.Rename(enabledPath, availablePath)
+	case confDPath != "" && fileExists(confDPath):
+		action = "disable"
+		err = os.Rename(confDPath, disabledPath)
+	case disabledPath != "" && fileExists(disabledPath):
+		action = "enable"
+		err = os.Rename(disabledPath, confDPath)
+	default:
+		return OutputMsg{Output: fmt.Sprintf("Cannot enable/disable '%s': it is not in a sites-available, sites-enabled or conf.d directory.\n\nSearched in:\n%s", siteName, describeSiteDirs())}
+	}
+
+	if err != nil {
+		return OutputMsg{Output: fmt.Sprintf("Failed to toggle site '%s': %s\n\nYou may need sudo/administrator privileges", siteName, err.Error())}
+	}
+
+	return tx.Validate(Change{Action: action, Kind: "site", Name: siteName})
+}
+
+// removeSymlink removes a sites-enabled entry, refusing to delete real files
+func removeSymlink(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s is not a symlink", path)
+	}
+	return os.Remove(path)
+}
+
+// siteNameFromFile strips the .disabled suffix so a site keeps its name in both states
+func siteNameFromFile(fileName string) string {
+	return strings.TrimSuffix(fileName, disabledSuffix)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
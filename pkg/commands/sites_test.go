@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"lazynginx/pkg/discovery"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// siteLayout creates the site directories next to the fake nginx.conf and
+// the files given by path relative to it; a value starting with "->" makes
+// a symlink to that path instead
+func siteLayout(t *testing.T, conf string, dirs []string, files map[string]string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(conf, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		path := filepath.Join(conf, name)
+		var err error
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			err = os.Symlink(filepath.Join(conf, target), path)
+		} else {
+			err = os.WriteFile(path, []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The directories are found next to nginx.conf on the next discovery
+	discovery.Refresh()
+}
+
+var (
+	debianLayout = []string{"sites-available", "sites-enabled"}
+	confDLayout  = []string{"conf.d"}
+)
+
+func TestSiteEnabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		dirs  []string
+		files map[string]string
+		want  bool
+	}{
+		{"linked in sites-enabled", debianLayout, map[string]string{"sites-available/blog": "server {}", "sites-enabled/blog": "->sites-available/blog"}, true},
+		{"only in sites-available", debianLayout, map[string]string{"sites-available/blog": "server {}"}, false},
+		{"regular file in sites-enabled", debianLayout, map[string]string{"sites-enabled/blog": "server {}"}, true},
+		{"conf.d file", confDLayout, map[string]string{"conf.d/blog": "server {}"}, true},
+		{"conf.d file with the .disabled suffix", confDLayout, map[string]string{"conf.d/blog.disabled": "server {}"}, false},
+		{"not in a site directory", confDLayout, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := useFakeNginx(t)
+			siteLayout(t, conf, tt.dirs, tt.files)
+			if got := SiteEnabled("blog"); got != tt.want {
+				t.Errorf("SiteEnabled = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleSite(t *testing.T) {
+	tests := []struct {
+		name    string
+		dirs    []string
+		files   map[string]string
+		action  string
+		ok      bool     // nginx -t passed and the change was kept
+		exist   []string // paths there after the toggle
+		missing []string // paths gone after the toggle
+	}{
+		{
+			name:   "enable links into sites-enabled",
+			dirs:   debianLayout,
+			files:  map[string]string{"sites-available/blog": "server {}"},
+			action: "enable",
+			ok:     true,
+			exist:  []string{"sites-available/blog", "sites-enabled/blog"},
+		},
+		{
+			name:    "disable removes the link",
+			dirs:    debianLayout,
+			files:   map[string]string{"sites-available/blog": "server {}", "sites-enabled/blog": "->sites-available/blog"},
+			action:  "disable",
+			ok:      true,
+			exist:   []string{"sites-available/blog"},
+			missing: []string{"sites-enabled/blog"},
+		},
+		{
+			name:    "disable parks a regular file in sites-available",
+			dirs:    debianLayout,
+			files:   map[string]string{"sites-enabled/blog": "server {}"},
+			action:  "disable",
+			ok:      true,
+			exist:   []string{"sites-available/blog"},
+			missing: []string{"sites-enabled/blog"},
+		},
+		{
+			name:    "enable that nginx rejects is rolled back",
+			dirs:    debianLayout,
+			files:   map[string]string{"sites-available/blog": "server { broken }"},
+			action:  "enable",
+			ok:      false,
+			exist:   []string{"sites-available/blog"},
+			missing: []string{"sites-enabled/blog"},
+		},
+		{
+			name:    "disable renames to .disabled",
+			dirs:    confDLayout,
+			files:   map[string]string{"conf.d/blog": "server {}"},
+			action:  "disable",
+			ok:      true,
+			exist:   []string{"conf.d/blog.disabled"},
+			missing: []string{"conf.d/blog"},
+		},
+		{
+			name:    "enable drops .disabled",
+			dirs:    confDLayout,
+			files:   map[string]string{"conf.d/blog.disabled": "server {}"},
+			action:  "enable",
+			ok:      true,
+			exist:   []string{"conf.d/blog"},
+			missing: []string{"conf.d/blog.disabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := useFakeNginx(t)
+			siteLayout(t, conf, tt.dirs, tt.files)
+			before := SiteEnabled("blog")
+
+			result := ToggleSite("blog")
+			msg, ok := result.(ConfigChangedMsg)
+			if !ok {
+				t.Fatalf("ToggleSite returned %T, want ConfigChangedMsg", result)
+			}
+			if msg.Change.Action != tt.action || msg.Change.Test.OK != tt.ok {
+				t.Errorf("Change = %s, test ok %v, want %s, %v", msg.Change.Action, msg.Change.Test.OK, tt.action, tt.ok)
+			}
+			if got := SiteEnabled("blog"); got != (before != tt.ok) {
+				t.Errorf("SiteEnabled after toggle = %v (before %v)", got, before)
+			}
+			for _, path := range tt.exist {
+				if _, err := os.Lstat(filepath.Join(conf, path)); err != nil {
+					t.Errorf("%s is missing after toggle", path)
+				}
+			}
+			for _, path := range tt.missing {
+				if _, err := os.Lstat(filepath.Join(conf, path)); err == nil {
+					t.Errorf("%s still exists after toggle", path)
+				}
+			}
+		})
+	}
+}
+
+func TestToggleSiteTwice(t *testing.T) {
+	for _, layout := range []struct {
+		name  string
+		dirs  []string
+		files map[string]string
+	}{
+		{"sites-enabled", debianLayout, map[string]string{"sites-available/blog": "server {}", "sites-enabled/blog": "->sites-available/blog"}},
+		{"conf.d", confDLayout, map[string]string{"conf.d/blog": "server {}"}},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			conf := useFakeNginx(t)
+			siteLayout(t, conf, layout.dirs, layout.files)
+
+			for _, want := range []string{"disable", "enable", "disable"} {
+				msg, ok := ToggleSite("blog").(ConfigChangedMsg)
+				if !ok || msg.Change.Action != want || !msg.Change.Test.OK {
+					t.Fatalf("ToggleSite = %+v, want a kept %s", msg, want)
+				}
+			}
+			if SiteEnabled("blog") {
+				t.Error("site is enabled after disable, enable, disable")
+			}
+		})
+	}
+}
+
+func TestToggleSiteNotFound(t *testing.T) {
+	conf := useFakeNginx(t)
+	siteLayout(t, conf, confDLayout, nil)
+
+	for _, name := range []string{"missing", "../nginx.conf", ""} {
+		result := ToggleSite(name)
+		if _, ok := result.(OutputMsg); !ok {
+			t.Errorf("ToggleSite(%q) = %T, want an OutputMsg", name, result)
+		}
+	}
+}
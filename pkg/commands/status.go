@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"lazynginx/pkg/discovery"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NginxStatusMsg carries the state of the nginx service
+type NginxStatusMsg struct {
+	Status Status
+}
+
+// Status describes the nginx service
+type Status struct {
+	Running     bool
+	PID         int           // master process, 0 when unknown
+	Uptime      time.Duration // since the master process started, 0 when unknown
+	Manager     string        // "systemd", "windows" or "process"; "" when nginx isn't installed
+	Description string        // one line for people, e.g. "✓ Nginx is running (systemd)"
+}
+
+// NginxStatus reports whether nginx is running and, when it is, the pid
+// and uptime of its master process
+func NginxStatus() Status {
+	running, manager, description := processState()
+	status := Status{Running: running, Manager: manager, Description: description}
+	if running {
+		status.PID = masterPID()
+		if status.PID != 0 {
+			status.Uptime = processUptime(status.PID)
+		}
+	}
+	return status
+}
+
+func CheckNginxStatus() tea.Msg {
+	return NginxStatusMsg{Status: NginxStatus()}
+}
+
+// masterPID reads the pid file, falling back to the oldest nginx process
+func masterPID() int {
+	if path := discovery.Get().PidPath; path != "" {
+		if content, err := os.ReadFile(path); err == nil {
+			if pid, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil {
+				return pid
+			}
+		}
+	}
+	output, err := exec.Command("pgrep", "-o", "-x", "nginx").Output()
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	return pid
+}
+
+// processUptime asks ps how long a process has been running, 0 if it can't tell
+func processUptime(pid int) time.Duration {
+	output, err := exec.Command("ps", "-o", "etime=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0
+	}
+	return parseElapsed(strings.TrimSpace(string(output)))
+}
+
+// parseElapsed reads the [[dd-]hh:]mm:ss elapsed time printed by ps
+func parseElapsed(etime string) time.Duration {
+	days := 0
+	if d, rest, ok := strings.Cut(etime, "-"); ok {
+		days, _ = strconv.Atoi(d)
+		etime = rest
+	}
+
+	var seconds int
+	for _, part := range strings.Split(etime, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(seconds)*time.Second
+}
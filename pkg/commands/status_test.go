@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseElapsed(t *testing.T) {
+	tests := []struct {
+		etime string
+		want  time.Duration
+	}{
+		{"05", 5 * time.Second},
+		{"05:06", 5*time.Minute + 6*time.Second},
+		{"02:03:04", 2*time.Hour + 3*time.Minute + 4*time.Second},
+		{"1-02:03:04", 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{"12-00:00:00", 12 * 24 * time.Hour},
+		{"", 0},
+		{"bogus", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseElapsed(tt.etime); got != tt.want {
+			t.Errorf("parseElapsed(%q) = %v, want %v", tt.etime, got, tt.want)
+		}
+	}
+}
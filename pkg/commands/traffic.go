@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+	"lazynginx/pkg/discovery"
+	"lazynginx/pkg/logs"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TrafficWindows are the time windows the Traffic view can aggregate over
+var TrafficWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 24 * time.Hour}
+
+const (
+	// trafficReadBytes bounds how much of the access log the Traffic view reads
+	trafficReadBytes = 32 * 1024 * 1024
+	trafficBuckets   = 40
+	trafficTop       = 10
+)
+
+// TrafficMsg carries the access log statistics for the Traffic view
+type TrafficMsg struct {
+	Stats   logs.Stats
+	Path    string
+	Format  string
+	Skipped int  // lines that didn't match the log format
+	Partial bool // the log is longer than what was read
+}
+
+// LoadTraffic aggregates the access log over the given window
+func LoadTraffic(window time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		path := discovery.Get().AccessLog
+		if path == "" {
+			return OutputMsg{Output: "Could not locate nginx access log file.\n\n" + describePaths()}
+		}
+
+		lines, partial, err := logs.ReadLast(path, trafficReadBytes)
+		if err != nil {
+			return OutputMsg{Output: fmt.Sprintf("Failed to read %s: %s\n\nYou may need sudo/administrator privileges", path, err.Error())}
+		}
+
+		format := AccessLogFormat(path)
+		records, skipped := ParseAccessLog(format, lines)
+
+		return TrafficMsg{
+			Stats:   logs.Summarize(records, window, time.Now(), trafficBuckets, trafficTop, partial),
+			Path:    path,
+			Format:  format.Name,
+			Skipped: skipped,
+			Partial: partial,
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"lazynginx/pkg/backup"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConfigChangedMsg is returned by actions that change configuration files
+type ConfigChangedMsg struct {
+	Change Change
+}
+
+// Change describes a change to the configuration files and how nginx took
+// it. When nginx -t rejects the change it is rolled back.
+type Change struct {
+	Action string // "create", "overwrite", "delete", "enable", "disable", "restore" or "edit"
+	Kind   string // "site", "reverse proxy", "backup" or "file"
+	Name   string
+	Path   string   // file written, removed or edited
+	Paths  []string // files put back by a restore
+
+	Type     string // template of a created site or reverse proxy
+	Root     string // document root of a created or deleted site
+	Location string // location of a created reverse proxy
+	Backends string // and the servers it passes to
+
+	Test        TestResult // nginx -t after the change; Test.OK means it was kept
+	RollbackErr error      // set when undoing a rejected change did not complete
+	Kept        string     // where a rejected edit was saved, so it isn't lost
+}
+
+// fileState is what a path looked like before a change
+type fileState struct {
+	path    string
+	exists  bool
+	symlink string // link target if the path was a symlink
+	content []byte
+	mode    os.FileMode
+}
+
+// Transaction records the files an action is about to touch so the change
+// can be validated with nginx -t and rolled back if nginx rejects it
+type Transaction struct {
+	states []fileState
+	backup *backup.Snapshot // nil if there was nothing to back up
+}
+
+// BeginTransaction snapshots the given paths. Paths that don't exist yet are
+// recorded as absent and removed again on rollback. Existing files are also
+// saved to the backup store under reason, so they can be restored later.
+func BeginTransaction(reason string, paths ...string) (*Transaction, error) {
+	snapshot, err := backup.Create(reason, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("backup failed: %v", err)
+	}
+	t := &Transaction{backup: snapshot}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		state := fileState{path: path}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			t.states = append(t.states, state)
+			continue
+		}
+
+		state.exists = true
+		state.mode = info.Mode().Perm()
+		if info.Mode()&os.ModeSymlink != 0 {
+			state.symlink, err = os.Readlink(path)
+		} else {
+			state.content, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		t.states = append(t.states, state)
+	}
+
+	return t, nil
+}
+
+// Changed reports whether any recorded path differs from its snapshot
+func (t *Transaction) Changed() bool {
+	for _, state := range t.states {
+		info, err := os.Lstat(state.path)
+		if err != nil {
+			if state.exists {
+				return true
+			}
+			continue
+		}
+		if !state.exists {
+			return true
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, _ := os.Readlink(state.path)
+			if target != state.symlink {
+				return true
+			}
+			continue
+		}
+		content, err := os.ReadFile(state.path)
+		if err != nil || state.symlink != "" || !bytes.Equal(content, state.content) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscardBackup removes the backup taken by BeginTransaction. Used when the
+// editor exits without changing the file, so opening it leaves no trace.
+func (t *Transaction) DiscardBackup() {
+	if t.backup != nil {
+		backup.Remove(t.backup.ID)
+		t.backup = nil
+	}
+}
+
+// Rollback puts every recorded path back the way it was
+func (t *Transaction) Rollback() error {
+	var failed []string
+
+	// Undo in reverse order: symlinks created last point to files created first
+	for i := len(t.states) - 1; i >= 0; i-- {
+		state := t.states[i]
+
+		// A regular file is rewritten in place to keep its owner; anything
+		// else (new files, symlinks) is removed and recreated
+		current, err := os.Lstat(state.path)
+		inPlace := err == nil && state.exists && state.symlink == "" && current.Mode().IsRegular()
+		if err == nil && !inPlace {
+			if err := os.Remove(state.path); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", state.path, err))
+				continue
+			}
+		}
+		if !state.exists {
+			continue
+		}
+
+		if state.symlink != "" {
+			err = os.Symlink(state.symlink, state.path)
+		} else {
+			err = os.WriteFile(state.path, state.content, state.mode)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", state.path, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not restore:\n- %s", strings.Join(failed, "\n- "))
+	}
+	return nil
+}
+
+// Validate runs nginx -t against the new state and rolls the change back
+// if the test fails. The returned message reports the change with the test
+// result, so a reload can be offered when it passed.
+func (t *Transaction) Validate(change Change) ConfigChangedMsg {
+	// The backup is only worth keeping while the files differ from it; it
+	// stays when a rollback fails, as it is then the only good copy
+	if !t.Changed() {
+		t.DiscardBackup()
+	}
+	change.Test = TestConfig()
+	if !change.Test.OK {
+		change.RollbackErr = t.Rollback()
+		if change.RollbackErr == nil {
+			t.DiscardBackup()
+		}
+	}
+	return ConfigChangedMsg{Change: change}
+}
+
+// ValidateEdit is Validate for files changed in an external editor. The
+// rejected version is kept in the temp directory so the edits aren't lost.
+func (t *Transaction) ValidateEdit(path string) ConfigChangedMsg {
+	rejected, _ := os.ReadFile(path)
+
+	msg := t.Validate(Change{Action: "edit", Kind: "file", Name: filepath.Base(path), Path: path})
+	if !msg.Change.Test.OK {
+		keep := filepath.Join(os.TempDir(), fmt.Sprintf("lazynginx-rejected-%s-%s", filepath.Base(path), time.Now().Format("20060102-150405")))
+		if err := os.WriteFile(keep, rejected, 0600); err == nil {
+			msg.Change.Kept = keep
+		}
+	}
+	return msg
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"lazynginx/pkg/backup"
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/discovery"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeNginx is an nginx stand-in whose -t fails while a file it would load
+// contains "broken"
+const fakeNginx = `#!/bin/sh
+dir=$(dirname "$0")
+if [ "$1" = "-t" ] && cat "$dir"/conf/*.conf "$dir"/conf/conf.d/*.conf "$dir"/conf/sites-enabled/* 2>/dev/null | grep -qs broken; then
+	echo "nginx: [emerg] broken config in $dir/conf/site.conf:1"
+	exit 1
+fi
+echo "nginx: configuration file $dir/conf/nginx.conf test is successful"
+`
+
+// useFakeNginx points config and discovery at a fake nginx in a temp dir,
+// with the backup store and the temp directory there too. It returns the
+// directory the config files go in.
+func useFakeNginx(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake nginx is a shell script")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("TMPDIR", dir)
+
+	conf := filepath.Join(dir, "conf")
+	if err := os.MkdirAll(conf, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(conf, "nginx.conf"), []byte("events {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "nginx")
+	if err := os.WriteFile(binary, []byte(fakeNginx), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := filepath.Join(dir, "config.yml")
+	yml := "nginx:\n  binary: " + binary + "\n  conf_path: " + filepath.Join(conf, "nginx.conf") + "\n"
+	if err := os.WriteFile(settings, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Load(settings); err != nil {
+		t.Fatal(err)
+	}
+	discovery.Refresh()
+	t.Cleanup(discovery.Refresh)
+	return conf
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionChanged(t *testing.T) {
+	conf := useFakeNginx(t)
+	site := filepath.Join(conf, "site.conf")
+	absent := filepath.Join(conf, "new.conf")
+	link := filepath.Join(conf, "link.conf")
+
+	tests := []struct {
+		name   string
+		change func()
+		want   bool
+	}{
+		{"untouched", func() {}, false},
+		{"rewritten with the same bytes", func() { writeFile(t, site, "server {}\n") }, false},
+		{"content changed", func() { writeFile(t, site, "server { listen 81; }\n") }, true},
+		{"file removed", func() { os.Remove(site) }, true},
+		{"file created", func() { writeFile(t, absent, "server {}\n") }, true},
+		{"symlink retargeted", func() { os.Remove(link); os.Symlink(absent, link) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, site, "server {}\n")
+			os.Remove(absent)
+			os.Remove(link)
+			if err := os.Symlink(site, link); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := BeginTransaction("test", site, absent, link)
+			if err != nil {
+				t.Fatalf("BeginTransaction: %v", err)
+			}
+			tt.change()
+			if got := tx.Changed(); got != tt.want {
+				t.Errorf("Changed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	conf := useFakeNginx(t)
+	site := filepath.Join(conf, "site.conf")
+	created := filepath.Join(conf, "new.conf")
+	link := filepath.Join(conf, "link.conf")
+	original := "server {\n    listen 80;\n}\n"
+
+	writeFile(t, site, original)
+	if err := os.Symlink(site, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := BeginTransaction("test", site, created, link)
+	if err != nil {
+		t.Fatalf("BeginTransaction: %v", err)
+	}
+	writeFile(t, site, "server {\n    listen 8080;\n}\n")
+	writeFile(t, created, "server {}\n")
+	os.Remove(link)
+	if err := os.Symlink(created, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if content, _ := os.ReadFile(site); string(content) != original {
+		t.Errorf("site.conf after rollback = %q, want %q", content, original)
+	}
+	if _, err := os.Lstat(created); !os.IsNotExist(err) {
+		t.Errorf("new.conf still exists after rollback (err %v)", err)
+	}
+	if target, _ := os.Readlink(link); target != site {
+		t.Errorf("link.conf points to %q after rollback, want %q", target, site)
+	}
+	if tx.Changed() {
+		t.Error("Changed() is true after rollback")
+	}
+}
+
+func TestTransactionValidate(t *testing.T) {
+	conf := useFakeNginx(t)
+	site := filepath.Join(conf, "site.conf")
+	original := "server { listen 80; }\n"
+
+	tests := []struct {
+		name    string
+		content string
+		ok      bool
+		want    string // site.conf after validating
+		backups int    // backups kept
+	}{
+		{"accepted change is kept", "server { listen 81; }\n", true, "server { listen 81; }\n", 1},
+		{"rejected change is rolled back", "server { broken }\n", false, original, 0},
+		{"unchanged file", original, true, original, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, site, original)
+			before, _ := backup.List()
+			tx, err := BeginTransaction("test", site)
+			if err != nil {
+				t.Fatalf("BeginTransaction: %v", err)
+			}
+			writeFile(t, site, tt.content)
+
+			msg := tx.Validate(Change{Action: "overwrite", Kind: "site", Name: "site", Path: site})
+			if msg.Change.Test.OK != tt.ok {
+				t.Errorf("Test.OK = %v, want %v (output %q)", msg.Change.Test.OK, tt.ok, msg.Change.Test.Output)
+			}
+			if msg.Change.RollbackErr != nil {
+				t.Errorf("RollbackErr = %v", msg.Change.RollbackErr)
+			}
+			if content, _ := os.ReadFile(site); string(content) != tt.want {
+				t.Errorf("site.conf = %q, want %q", content, tt.want)
+			}
+			if after, _ := backup.List(); len(after)-len(before) != tt.backups {
+				t.Errorf("%d backups kept, want %d", len(after)-len(before), tt.backups)
+			}
+		})
+	}
+}
+
+func TestTransactionValidateEdit(t *testing.T) {
+	conf := useFakeNginx(t)
+	site := filepath.Join(conf, "site.conf")
+	writeFile(t, site, "server {}\n")
+
+	tx, err := BeginTransaction("edit site.conf", site)
+	if err != nil {
+		t.Fatalf("BeginTransaction: %v", err)
+	}
+	writeFile(t, site, "server { broken }\n")
+
+	msg := tx.ValidateEdit(site)
+	if msg.Change.Test.OK {
+		t.Fatal("broken edit passed the test")
+	}
+	if len(msg.Change.Test.Diagnostics) != 1 {
+		t.Errorf("Diagnostics = %+v, want the emerg line", msg.Change.Test.Diagnostics)
+	}
+	if content, _ := os.ReadFile(site); string(content) != "server {}\n" {
+		t.Errorf("site.conf = %q, want the original", content)
+	}
+
+	// The rejected edit is kept so it isn't lost
+	if !strings.HasPrefix(msg.Change.Kept, os.TempDir()) {
+		t.Fatalf("Kept = %q, want a file in %s", msg.Change.Kept, os.TempDir())
+	}
+	if kept, _ := os.ReadFile(msg.Change.Kept); string(kept) != "server { broken }\n" {
+		t.Errorf("kept edit = %q", kept)
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the user settings read from config.yml.
+// Empty values mean "not set" and keep the built-in behaviour.
+type Config struct {
+	Editor       string      `yaml:"editor"`         // editor command, e.g. "nvim" or "code --wait", or "builtin" for the built-in editor
+	EditorLine   string      `yaml:"editor_line"`    // arguments opening {file} at {line}, e.g. "+{line} {file}"; chosen by editor name when empty
+	Nginx        NginxConfig `yaml:"nginx"`          // overrides for path discovery
+	PHPFPMSocket string      `yaml:"php_fpm_socket"` // fastcgi_pass target used by the PHP site templates
+	WebRoot      string      `yaml:"web_root"`       // parent directory of new site roots
+	TailLines    int         `yaml:"tail_lines"`     // number of log lines shown by the log views
+	Theme        string      `yaml:"theme"`          // "default", "light" or "monochrome"
+	LineNumbers  bool        `yaml:"line_numbers"`   // config views start with line numbers shown
+}
+
+// NginxConfig overrides the paths found by nginx -V and the parsed config
+type NginxConfig struct {
+	Binary         string `yaml:"binary"`
+	ConfPath       string `yaml:"conf_path"`
+	SitesAvailable string `yaml:"sites_available"`
+	SitesEnabled   string `yaml:"sites_enabled"`
+	ConfD          string `yaml:"conf_d"`
+	ErrorLog       string `yaml:"error_log"`
+	AccessLog      string `yaml:"access_log"`
+}
+
+// Default returns the settings used when no config file exists
+func Default() *Config {
+	return &Config{
+		PHPFPMSocket: "unix:/var/run/php/php8.1-fpm.sock",
+		WebRoot:      "/var/www",
+		TailLines:    50,
+		Theme:        "default",
+	}
+}
+
+var (
+	current = Default()
+	mu      sync.RWMutex
+)
+
+// Get returns the loaded settings (defaults until Load is called)
+func Get() *Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Dir returns the lazynginx configuration directory:
+// $XDG_CONFIG_HOME/lazynginx, falling back to ~/.config/lazynginx
+// (%AppData%\lazynginx on Windows)
+func Dir() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "lazynginx")
+	}
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, "lazynginx")
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "lazynginx")
+	}
+	return "lazynginx"
+}
+
+// DefaultPath returns the location of config.yml in Dir
+func DefaultPath() string {
+	return filepath.Join(Dir(), "config.yml")
+}
+
+// Load reads the settings from path, or from DefaultPath when path is empty.
+// A missing default file is not an error; a missing explicit file is.
+func Load(path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+	}
+
+	cfg := Default()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("could not read config file %s: %v", path, err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	// Keep defaults for values that were explicitly blanked
+	defaults := Default()
+	if cfg.PHPFPMSocket == "" {
+		cfg.PHPFPMSocket = defaults.PHPFPMSocket
+	}
+	if cfg.WebRoot == "" {
+		cfg.WebRoot = defaults.WebRoot
+	}
+	if cfg.TailLines <= 0 {
+		cfg.TailLines = defaults.TailLines
+	}
+	if cfg.Theme == "" {
+		cfg.Theme = defaults.Theme
+	}
+
+	mu.Lock()
+	current = cfg
+	mu.Unlock()
+	return nil
+}
@@ -0,0 +1,189 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpKind is the kind of a diff line
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Delete
+	Insert
+)
+
+// Line is one line of an edit script
+type Line struct {
+	Kind OpKind
+	Text string
+}
+
+// Lines computes the line edit script turning a into b.
+// Common prefix and suffix are trimmed before the LCS table is built, so
+// the cost depends on the size of the changed region, not the whole file.
+func Lines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var script []Line
+	for _, text := range a[:prefix] {
+		script = append(script, Line{Kind: Equal, Text: text})
+	}
+	script = append(script, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		script = append(script, Line{Kind: Equal, Text: text})
+	}
+	return script
+}
+
+// lcs builds the edit script of the changed region with a longest common subsequence table
+func lcs(a, b []string) []Line {
+	n, m := len(a), len(b)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	var script []Line
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			script = append(script, Line{Kind: Equal, Text: a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			script = append(script, Line{Kind: Delete, Text: a[i]})
+			i++
+		default:
+			script = append(script, Line{Kind: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		script = append(script, Line{Kind: Delete, Text: a[i]})
+	}
+	for ; j < m; j++ {
+		script = append(script, Line{Kind: Insert, Text: b[j]})
+	}
+	return script
+}
+
+// Unified renders a unified diff (like diff -u) between two texts with the
+// given number of context lines. It returns "" when the texts are equal.
+func Unified(fromName, toName, from, to string, context int) string {
+	script := Lines(splitLines(from), splitLines(to))
+
+	// Find the changed lines, then group them into hunks with context
+	type hunk struct{ start, end int } // indexes into script, end exclusive
+	var hunks []hunk
+	for i, line := range script {
+		if line.Kind == Equal {
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + context + 1
+		if end > len(script) {
+			end = len(script)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	s := strings.Builder{}
+	s.WriteString("--- " + fromName + "\n")
+	s.WriteString("+++ " + toName + "\n")
+
+	// Line numbers in each file at the start of the script position
+	fromLine, toLine := 1, 1
+	pos := 0
+	for _, h := range hunks {
+		for ; pos < h.start; pos++ {
+			fromLine, toLine = advance(script[pos].Kind, fromLine, toLine)
+		}
+
+		fromCount, toCount := 0, 0
+		for _, line := range script[h.start:h.end] {
+			if line.Kind != Insert {
+				fromCount++
+			}
+			if line.Kind != Delete {
+				toCount++
+			}
+		}
+		s.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount)))
+
+		for ; pos < h.end; pos++ {
+			line := script[pos]
+			switch line.Kind {
+			case Equal:
+				s.WriteString(" " + line.Text + "\n")
+			case Delete:
+				s.WriteString("-" + line.Text + "\n")
+			case Insert:
+				s.WriteString("+" + line.Text + "\n")
+			}
+			fromLine, toLine = advance(line.Kind, fromLine, toLine)
+		}
+	}
+
+	return s.String()
+}
+
+func advance(kind OpKind, fromLine, toLine int) (int, int) {
+	if kind != Insert {
+		fromLine++
+	}
+	if kind != Delete {
+		toLine++
+	}
+	return fromLine, toLine
+}
+
+// hunkRange formats "start,count" the way diff -u does (start is 0 for empty ranges)
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, ignoring the final newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,407 @@
+package discovery
+
+import (
+	"lazynginx/pkg/config"
+	"lazynginx/pkg/nginxconf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Paths holds every location lazynginx needs to know about an nginx install
+type Paths struct {
+	Binary         string   // nginx executable
+	Version        string   // e.g. "nginx/1.24.0"
+	Prefix         string   // --prefix
+	ConfPath       string   // --conf-path (main nginx.conf)
+	ConfPrefix     string   // directory of ConfPath, base for relative includes
+	ErrorLog       string   // main error log (error_log directive or --error-log-path)
+	AccessLog      string   // main access log (http-level access_log or --http-log-path)
+	ErrorLogs      []string // every error_log file referenced by the config
+	AccessLogs     []string // every access_log file referenced by the config
+	PidPath        string   // pid file of the master process (pid directive or --pid-path)
+	SitesAvailable string   // Debian-style sites-available directory, if any
+	SitesEnabled   string   // Debian-style sites-enabled directory, if any
+	ConfD          string   // conf.d style directory, if any
+}
+
+// fallbackBinaries are tried when nginx is not in PATH
+var fallbackBinaries = []string{
+	"/usr/sbin/nginx",
+	"/usr/local/sbin/nginx",
+	"/usr/local/nginx/sbin/nginx",
+	"/opt/nginx/sbin/nginx",
+	"/opt/homebrew/bin/nginx",
+	"C:\\nginx\\nginx.exe",
+}
+
+// fallbackPrefixes are tried when nginx -V can't be run (binary missing or not executable)
+var fallbackPrefixes = []string{
+	"/etc/nginx",
+	"/usr/local/nginx",
+	"/opt/nginx",
+	"/usr/local/etc/nginx",
+	"/opt/homebrew/etc/nginx",
+	"C:\\nginx",
+}
+
+// fallbackLogDirs are checked for error.log/access.log when neither nginx -V
+// nor the configuration names the log files
+var fallbackLogDirs = []string{
+	"/var/log/nginx",
+	"/usr/local/var/log/nginx",
+	"/opt/homebrew/var/log/nginx",
+}
+
+var (
+	cached *Paths
+	mu     sync.Mutex
+)
+
+// Get returns the discovered paths, running discovery on first use
+func Get() *Paths {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cached == nil {
+		cached = Discover()
+	}
+	return cached
+}
+
+// Refresh forgets the cached paths so the next Get runs discovery again,
+// e.g. after the configuration has been edited
+func Refresh() {
+	mu.Lock()
+	defer mu.Unlock()
+	cached = nil
+}
+
+// Discover finds the nginx binary, reads its compiled-in paths from nginx -V
+// and completes them with the error_log/access_log directives and include
+// directories of the parsed configuration
+func Discover() *Paths {
+	overrides := config.Get().Nginx
+
+	p := &Paths{Binary: valueOr(overrides.Binary, findBinary())}
+
+	if p.Binary != "" {
+		if output, err := exec.Command(p.Binary, "-V").CombinedOutput(); err == nil {
+			p.applyBuildInfo(string(output))
+		}
+	}
+
+	// nginx -V unavailable: use the first conventional prefix that has a config
+	if p.ConfPath == "" {
+		for _, prefix := range fallbackPrefixes {
+			for _, conf := range []string{filepath.Join(prefix, "nginx.conf"), filepath.Join(prefix, "conf", "nginx.conf")} {
+				if _, err := os.Stat(conf); err == nil {
+					p.Prefix = prefix
+					p.ConfPath = conf
+					break
+				}
+			}
+			if p.ConfPath != "" {
+				break
+			}
+		}
+	}
+
+	if overrides.ConfPath != "" {
+		p.ConfPath = overrides.ConfPath
+	}
+
+	if p.ConfPath != "" {
+		p.ConfPrefix = filepath.Dir(p.ConfPath)
+		if p.Prefix == "" {
+			p.Prefix = p.ConfPrefix
+		}
+	}
+
+	p.applyConfig()
+	p.applyOverrides(overrides)
+
+	if p.ErrorLog == "" {
+		p.ErrorLog = p.findLog("error.log")
+	}
+	if p.AccessLog == "" {
+		p.AccessLog = p.findLog("access.log")
+	}
+	return p
+}
+
+// findLog looks for a log file in the prefix and the conventional log directories
+func (p *Paths) findLog(name string) string {
+	dirs := fallbackLogDirs
+	if p.Prefix != "" {
+		dirs = append([]string{filepath.Join(p.Prefix, "logs")}, dirs...)
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// findBinary returns the nginx executable from PATH or a known location
+func findBinary() string {
+	if path, err := exec.LookPath("nginx"); err == nil {
+		return path
+	}
+	for _, path := range fallbackBinaries {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// applyBuildInfo reads the configure arguments printed by nginx -V
+func (p *Paths) applyBuildInfo(output string) {
+	args := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "nginx version:") {
+			p.Version = strings.TrimSpace(strings.TrimPrefix(line, "nginx version:"))
+		}
+		if !strings.HasPrefix(line, "configure arguments:") {
+			continue
+		}
+		for _, arg := range configureArgs(strings.TrimPrefix(line, "configure arguments:")) {
+			if key, value, ok := strings.Cut(arg, "="); ok {
+				args[key] = value
+			}
+		}
+	}
+
+	// nginx defaults when an argument wasn't given at build time
+	p.Prefix = args["--prefix"]
+	if p.Prefix == "" {
+		if runtime.GOOS == "windows" {
+			p.Prefix = filepath.Dir(p.Binary)
+		} else {
+			p.Prefix = "/usr/local/nginx"
+		}
+	}
+
+	p.ConfPath = p.resolve(valueOr(args["--conf-path"], "conf/nginx.conf"))
+	p.ErrorLog = p.resolve(valueOr(args["--error-log-path"], "logs/error.log"))
+	p.AccessLog = p.resolve(valueOr(args["--http-log-path"], "logs/access.log"))
+	p.PidPath = p.resolve(valueOr(args["--pid-path"], "logs/nginx.pid"))
+}
+
+// configureArgs splits the configure arguments the way the shell that ran
+// configure did, so a quoted value like --with-cc-opt='-O2 -g' or a path
+// with spaces stays one argument. The quotes are removed.
+func configureArgs(line string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	for _, c := range line {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// applyConfig reads log and include directives from the effective configuration
+func (p *Paths) applyConfig() {
+	if p.ConfPath == "" {
+		return
+	}
+
+	tree, err := nginxconf.Resolve(p.ConfPath, p.ConfPrefix)
+	if err != nil {
+		p.applyDirectoryDefaults()
+		return
+	}
+
+	nginxconf.Walk(tree.Directives, func(d *nginxconf.Directive, parents []*nginxconf.Directive) bool {
+		switch d.Name {
+		case "error_log":
+			if path := p.LogPath(d.Arg(0)); path != "" {
+				p.ErrorLogs = appendUnique(p.ErrorLogs, path)
+				// The main-level error_log replaces the compiled-in one
+				if len(parents) == 0 {
+					p.ErrorLog = path
+				}
+			}
+		case "access_log":
+			if path := p.LogPath(d.Arg(0)); path != "" {
+				p.AccessLogs = appendUnique(p.AccessLogs, path)
+				if len(parents) == 1 && parents[0].Name == "http" {
+					p.AccessLog = path
+				}
+			}
+		case "pid":
+			if len(parents) == 0 && d.Arg(0) != "" {
+				p.PidPath = p.resolve(d.Arg(0))
+			}
+		}
+		return true
+	})
+
+	// Include directories tell us which site layout is in use
+	for _, conf := range tree.Files {
+		for _, include := range nginxconf.Find(conf.Directives, "include") {
+			dir := filepath.Dir(p.resolveConf(include.Arg(0)))
+			switch filepath.Base(dir) {
+			case "sites-enabled":
+				p.SitesEnabled = dir
+				p.SitesAvailable = filepath.Join(filepath.Dir(dir), "sites-available")
+			case "conf.d":
+				p.ConfD = dir
+			}
+		}
+	}
+
+	p.applyDirectoryDefaults()
+
+	if p.ErrorLog != "" {
+		p.ErrorLogs = appendUnique([]string{p.ErrorLog}, p.ErrorLogs...)
+	}
+	if p.AccessLog != "" {
+		p.AccessLogs = appendUnique([]string{p.AccessLog}, p.AccessLogs...)
+	}
+}
+
+// applyOverrides replaces discovered directories and logs with the ones set in config.yml
+func (p *Paths) applyOverrides(overrides config.NginxConfig) {
+	if overrides.SitesAvailable != "" {
+		p.SitesAvailable = overrides.SitesAvailable
+	}
+	if overrides.SitesEnabled != "" {
+		p.SitesEnabled = overrides.SitesEnabled
+	}
+	if overrides.ConfD != "" {
+		p.ConfD = overrides.ConfD
+	}
+	if overrides.ErrorLog != "" {
+		p.ErrorLog = overrides.ErrorLog
+		p.ErrorLogs = appendUnique([]string{p.ErrorLog}, p.ErrorLogs...)
+	}
+	if overrides.AccessLog != "" {
+		p.AccessLog = overrides.AccessLog
+		p.AccessLogs = appendUnique([]string{p.AccessLog}, p.AccessLogs...)
+	}
+}
+
+// applyDirectoryDefaults fills site directories that exist next to nginx.conf
+// but weren't found through include directives
+func (p *Paths) applyDirectoryDefaults() {
+	if p.ConfPrefix == "" {
+		return
+	}
+	if p.SitesAvailable == "" && isDir(filepath.Join(p.ConfPrefix, "sites-available")) {
+		p.SitesAvailable = filepath.Join(p.ConfPrefix, "sites-available")
+	}
+	if p.SitesEnabled == "" && isDir(filepath.Join(p.ConfPrefix, "sites-enabled")) {
+		p.SitesEnabled = filepath.Join(p.ConfPrefix, "sites-enabled")
+	}
+	if p.ConfD == "" && isDir(filepath.Join(p.ConfPrefix, "conf.d")) {
+		p.ConfD = filepath.Join(p.ConfPrefix, "conf.d")
+	}
+}
+
+// SiteDirs returns the directories that hold site configuration files,
+// sites-available first so disabled sites are listed too
+func (p *Paths) SiteDirs() []string {
+	var dirs []string
+	for _, dir := range []string{p.SitesAvailable, p.SitesEnabled, p.ConfD} {
+		if dir != "" && isDir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// WritableSiteDir returns the directory new sites are written to
+func (p *Paths) WritableSiteDir() string {
+	if p.SitesAvailable != "" && isDir(p.SitesAvailable) {
+		return p.SitesAvailable
+	}
+	if p.ConfD != "" && isDir(p.ConfD) {
+		return p.ConfD
+	}
+	return ""
+}
+
+// LogPath turns a log directive argument into a file path.
+// syslog, stderr, memory buffers and "off" have no file.
+func (p *Paths) LogPath(arg string) string {
+	if arg == "" || arg == "off" || arg == "stderr" ||
+		strings.HasPrefix(arg, "syslog:") || strings.HasPrefix(arg, "memory:") || strings.Contains(arg, "$") {
+		return ""
+	}
+	return p.resolve(arg)
+}
+
+// resolve makes a path relative to the nginx prefix absolute
+func (p *Paths) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.Prefix, path)
+}
+
+// resolveConf makes a path relative to the configuration prefix absolute
+func (p *Paths) resolveConf(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.ConfPrefix, path)
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,113 @@
+package discovery
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// ubuntuV is nginx -V of the Ubuntu 22.04 package
+const ubuntuV = `nginx version: nginx/1.18.0 (Ubuntu)
+built with OpenSSL 3.0.2 15 Mar 2022
+TLS SNI support enabled
+configure arguments: --with-cc-opt='-g -O2 -ffile-prefix-map=/build/nginx-zctdR4/nginx-1.18.0=. -flto=auto -ffat-lto-objects -flto=auto -ffat-lto-objects -fstack-protector-strong -Wformat -Werror=format-security -fPIC -Wdate-time -D_FORTIFY_SOURCE=2' --with-ld-opt='-Wl,-Bsymbolic-functions -flto=auto -ffat-lto-objects -flto=auto -Wl,-z,relro -Wl,-z,now -fPIC' --prefix=/usr/share/nginx --conf-path=/etc/nginx/nginx.conf --http-log-path=/var/log/nginx/access.log --error-log-path=/var/log/nginx/error.log --lock-path=/var/lock/nginx.lock --pid-path=/run/nginx.pid --modules-path=/usr/lib/nginx/modules --http-client-body-temp-path=/var/lib/nginx/body --http-fastcgi-temp-path=/var/lib/nginx/fastcgi --http-proxy-temp-path=/var/lib/nginx/proxy --http-scgi-temp-path=/var/lib/nginx/scgi --http-uwsgi-temp-path=/var/lib/nginx/uwsgi --with-compat --with-debug --with-pcre-jit --with-http_ssl_module --with-http_stub_status_module --with-http_realip_module --with-http_auth_request_module --with-http_v2_module --with-http_dav_module --with-http_slice_module --with-threads --add-dynamic-module=/build/nginx-zctdR4/nginx-1.18.0/debian/modules/http-geoip2 --with-http_addition_module --with-http_gunzip_module --with-http_gzip_static_module --with-http_sub_module
+`
+
+// nginxOrgV is nginx -V of the nginx.org package, with the paths before
+// the quoted compiler options
+const nginxOrgV = `nginx version: nginx/1.24.0
+built by gcc 11.2.0 (Ubuntu 11.2.0-19ubuntu1)
+built with OpenSSL 3.0.2 15 Mar 2022
+TLS SNI support enabled
+configure arguments: --prefix=/etc/nginx --sbin-path=/usr/sbin/nginx --modules-path=/usr/lib/nginx/modules --conf-path=/etc/nginx/nginx.conf --error-log-path=/var/log/nginx/error.log --http-log-path=/var/log/nginx/access.log --pid-path=/var/run/nginx.pid --lock-path=/var/run/nginx.lock --http-client-body-temp-path=/var/cache/nginx/client_temp --with-compat --with-file-aio --with-threads --with-http_ssl_module --with-cc-opt='-g -O2 -ffile-prefix-map=/data/builder/debuild/nginx-1.24.0/debian/debuild-base/nginx-1.24.0=. -flto=auto -ffat-lto-objects -fstack-protector-strong -Wformat -Werror=format-security -Wp,-D_FORTIFY_SOURCE=2 -fPIC' --with-ld-opt='-Wl,-Bsymbolic-functions -flto=auto -ffat-lto-objects -Wl,-z,relro -Wl,-z,now -Wl,--as-needed -pie'
+`
+
+func TestApplyBuildInfo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the expected paths are Unix paths")
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		want   Paths
+	}{
+		{
+			name:   "ubuntu package",
+			output: ubuntuV,
+			want: Paths{
+				Version:   "nginx/1.18.0 (Ubuntu)",
+				Prefix:    "/usr/share/nginx",
+				ConfPath:  "/etc/nginx/nginx.conf",
+				ErrorLog:  "/var/log/nginx/error.log",
+				AccessLog: "/var/log/nginx/access.log",
+				PidPath:   "/run/nginx.pid",
+			},
+		},
+		{
+			name:   "nginx.org package",
+			output: nginxOrgV,
+			want: Paths{
+				Version:   "nginx/1.24.0",
+				Prefix:    "/etc/nginx",
+				ConfPath:  "/etc/nginx/nginx.conf",
+				ErrorLog:  "/var/log/nginx/error.log",
+				AccessLog: "/var/log/nginx/access.log",
+				PidPath:   "/var/run/nginx.pid",
+			},
+		},
+		{
+			name:   "source build with the defaults",
+			output: "nginx version: nginx/1.25.3\nbuilt by gcc 12.2.0\nconfigure arguments: --with-http_ssl_module\n",
+			want: Paths{
+				Version:   "nginx/1.25.3",
+				Prefix:    "/usr/local/nginx",
+				ConfPath:  "/usr/local/nginx/conf/nginx.conf",
+				ErrorLog:  "/usr/local/nginx/logs/error.log",
+				AccessLog: "/usr/local/nginx/logs/access.log",
+				PidPath:   "/usr/local/nginx/logs/nginx.pid",
+			},
+		},
+		{
+			name:   "quoted values with spaces",
+			output: "nginx version: nginx/1.25.3\nconfigure arguments: --with-cc-opt='-O2 -g' --prefix=\"/opt/my nginx\" --conf-path='/opt/my nginx/conf/nginx.conf' --error-log-path=logs/error.log\n",
+			want: Paths{
+				Version:   "nginx/1.25.3",
+				Prefix:    "/opt/my nginx",
+				ConfPath:  "/opt/my nginx/conf/nginx.conf",
+				ErrorLog:  "/opt/my nginx/logs/error.log",
+				AccessLog: "/opt/my nginx/logs/access.log",
+				PidPath:   "/opt/my nginx/logs/nginx.pid",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Paths{}
+			p.applyBuildInfo(tt.output)
+			if !reflect.DeepEqual(*p, tt.want) {
+				t.Errorf("applyBuildInfo =\n%+v\nwant\n%+v", *p, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{" --prefix=/etc/nginx --with-compat", []string{"--prefix=/etc/nginx", "--with-compat"}},
+		{" --with-cc-opt='-g -O2' --prefix=/x", []string{"--with-cc-opt=-g -O2", "--prefix=/x"}},
+		{` --with-ld-opt="-Wl,-z,relro -pie"`, []string{"--with-ld-opt=-Wl,-z,relro -pie"}},
+		{` --with-cc-opt='-DNAME="x y"'`, []string{`--with-cc-opt=-DNAME="x y"`}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := configureArgs(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("configureArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
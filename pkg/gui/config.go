@@ -0,0 +1,50 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	// GutterStyle draws the line numbers of config views
+	GutterStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+	// FoldStyle draws what stands for the lines of a folded block
+	FoldStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#BD93F9"))
+)
+
+// ConfigRow is a line of a config view: a line of the configuration text,
+// which may stand for a folded block
+type ConfigRow struct {
+	Index  int // line of the configuration text, from 0
+	Number int // line number of its file, 0 for none
+	Folded int // lines hidden under it
+}
+
+// RenderConfig renders the rows of a config view from the highlighted lines
+// of the configuration, pointing at the row under the cursor. With gutter
+// set each row shows the line number of its file.
+func RenderConfig(lines []string, rows []ConfigRow, cursor int, gutter bool) string {
+	out := make([]string, len(rows))
+	for i, row := range rows {
+		line := lines[row.Index]
+		if row.Folded > 0 {
+			line += " " + FoldStyle.Render(fmt.Sprintf("⋯ %d lines }", row.Folded))
+		}
+		if gutter {
+			number := ""
+			if row.Number > 0 {
+				number = fmt.Sprint(row.Number)
+			}
+			line = GutterStyle.Render(fmt.Sprintf("%4s", number)) + "  " + line
+		}
+		marker := " "
+		if i == cursor {
+			marker = "▶"
+		}
+		out[i] = marker + line
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,149 @@
+package gui
+
+import (
+	"fmt"
+	"lazynginx/pkg/textedit"
+	"lazynginx/pkg/utils"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jesseduffield/lazycore/pkg/boxlayout"
+)
+
+var (
+	// CursorStyle draws the cursor of the built-in editor
+	CursorStyle = lipgloss.NewStyle().Reverse(true)
+
+	// BraceStyle marks the brace at the cursor and the one it pairs with
+	BraceStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#1F1F1F")).
+			Background(lipgloss.Color("#FFB86C")).
+			Bold(true)
+)
+
+// EditorTabWidth is how many cells a tab takes in the built-in editor
+const EditorTabWidth = 4
+
+// editorLines returns how many lines of text the built-in editor shows in a
+// details panel of the given height: the panel less its title and status line
+func editorLines(boxHeight int) int {
+	return utils.Max(boxHeight-2-2-1, 1)
+}
+
+// viewEditorWithDim draws the built-in editor in place of the details panel:
+// the highlighted text with the cursor and matching braces, and a status line
+func viewEditorWithDim(m ModelView, dim boxlayout.Dimensions, buffer *textedit.Buffer, path string, note string) string {
+	boxWidth := dim.X1 - dim.X0 + 1
+	boxHeight := dim.Y1 - dim.Y0 + 1
+	contentWidth := utils.Max(boxWidth-4, 20)
+	visible := editorLines(boxHeight)
+
+	title := " Edit " + filepath.Base(path) + " "
+	if buffer.Modified() {
+		title += "[+] "
+	}
+
+	s := strings.Builder{}
+	s.WriteString(TitleStyle.Render(title) + "\n\n")
+
+	lines := buffer.Lines()
+	styles := ConfigStyles(buffer.String())
+	row, col := buffer.Cursor()
+
+	// The braces are marked over the syntax colors
+	if brace, match, ok := buffer.MatchBrace(); ok {
+		for _, at := range []textedit.Position{brace, match} {
+			styles[at.Row] = append([]*lipgloss.Style(nil), styles[at.Row]...)
+			styles[at.Row][at.Col] = &BraceStyle
+		}
+	}
+
+	// Keep the cursor in view even when the app's guess of the panel size is off
+	top, left := buffer.Top, buffer.Left
+	if row < top {
+		top = row
+	}
+	if row >= top+visible {
+		top = row - visible + 1
+	}
+	if x := textedit.DisplayWidth(lines[row][:col], EditorTabWidth); x >= left+contentWidth {
+		left = x - contentWidth + 1
+	}
+
+	for i := top; i < top+visible; i++ {
+		if i >= len(lines) {
+			s.WriteString("\n")
+			continue
+		}
+		line, lineStyles := lines[i], styles[i]
+		if i == row {
+			if col == len(line) {
+				line += " "
+			}
+			lineStyles = append(append([]*lipgloss.Style(nil), lineStyles...), nil)
+			lineStyles[col] = &CursorStyle
+		}
+		s.WriteString(renderCells(line, lineStyles, left, contentWidth) + "\n")
+	}
+
+	status := fmt.Sprintf("%s  Ln %d, Col %d", path, row+1, buffer.Column())
+	if note != "" {
+		status += "  " + note
+	}
+	s.WriteString(InfoStyle.Render(ansi.Truncate(status, contentWidth, "...")))
+
+	borderColor := UnfocusedBorderColor
+	if m.GetActivePanel() == 2 {
+		borderColor = FocusedBorderColor
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Width(boxWidth - 2).
+		Height(boxHeight - 2).
+		Render(s.String())
+}
+
+// renderCells draws the cells of a line from column left, at most width
+// cells wide. Tabs are expanded to EditorTabWidth spaces.
+func renderCells(line string, styles []*lipgloss.Style, left int, width int) string {
+	type cell struct {
+		text  string
+		style *lipgloss.Style
+	}
+	var cells []cell
+	for i, r := range line {
+		if r == '\t' {
+			for j := 0; j < EditorTabWidth; j++ {
+				cells = append(cells, cell{" ", styles[i]})
+			}
+			continue
+		}
+		cells = append(cells, cell{string(r), styles[i]})
+	}
+	if left >= len(cells) {
+		return ""
+	}
+	cells = cells[left:utils.Min(left+width, len(cells))]
+
+	s := strings.Builder{}
+	for start := 0; start < len(cells); {
+		var run strings.Builder
+		end := start
+		for end < len(cells) && cells[end].style == cells[start].style {
+			run.WriteString(cells[end].text)
+			end++
+		}
+		if cells[start].style == nil {
+			s.WriteString(run.String())
+		} else {
+			s.WriteString(cells[start].style.Render(run.String()))
+		}
+		start = end
+	}
+	return s.String()
+}
@@ -0,0 +1,89 @@
+package gui
+
+import (
+	"fmt"
+	"lazynginx/pkg/logs"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// LevelStyle returns the style error log lines of a severity are drawn with
+func LevelStyle(level string) lipgloss.Style {
+	switch level {
+	case "emerg", "alert", "crit":
+		return ErrorStyle
+	case "error":
+		return ErrorStyle.UnsetBold()
+	case "warn":
+		return WarningStyle
+	case "notice", "info":
+		return InfoStyle
+	case "debug":
+		return lipgloss.NewStyle().Foreground(UnfocusedBorderColor)
+	}
+	return lipgloss.NewStyle()
+}
+
+// ColorErrorLine colors a raw error log line by its severity
+func ColorErrorLine(line string) string {
+	entry, ok := logs.ParseErrorLine(line)
+	if !ok {
+		return line
+	}
+	return LevelStyle(entry.Level).Render(line)
+}
+
+// ErrorLogHeaderLines is how many lines RenderErrorLog writes before the first entry
+const ErrorLogHeaderLines = 5
+
+// RenderErrorLog renders error log entries as a list with a cursor under a
+// heading naming the log and the lines read. grouped lists each repeated message once with its count; otherwise every
+// line is shown. minLevel is the lowest severity shown, "" for all.
+func RenderErrorLog(heading string, skipped int, groups []*logs.ErrorGroup, grouped bool, minLevel string, cursor int) string {
+	s := strings.Builder{}
+
+	shown := "all levels"
+	if minLevel != "" {
+		shown = minLevel + " and above"
+	}
+	total := 0
+	for _, g := range groups {
+		total += g.Count
+	}
+
+	s.WriteString(heading + ":\n")
+	s.WriteString(fmt.Sprintf("Showing %s: %d entries, %d distinct messages\n", shown, total, len(groups)))
+	if skipped > 0 {
+		s.WriteString(InfoStyle.Render(fmt.Sprintf("Skipped %d lines that don't start an entry", skipped)) + "\n")
+	} else {
+		s.WriteString("\n")
+	}
+	s.WriteString(strings.Repeat("─", 50) + "\n\n")
+
+	if len(groups) == 0 {
+		s.WriteString("No entries at this level.\n")
+		return s.String()
+	}
+
+	for i, g := range groups {
+		marker := "  "
+		if i == cursor {
+			marker = "▶ "
+		}
+
+		if !grouped {
+			s.WriteString(marker + LevelStyle(g.Level).Render(g.Latest.Raw) + "\n")
+			continue
+		}
+
+		line := fmt.Sprintf("%5s  %s  %s %s", fmt.Sprintf("×%d", g.Count), g.Last.Format("01-02 15:04:05"),
+			LevelStyle(g.Level).Render("["+g.Level+"]"), g.Message)
+		if g.Upstream != "" {
+			line += InfoStyle.Render("  → " + g.Upstream)
+		}
+		s.WriteString(marker + line + "\n")
+	}
+
+	return s.String()
+}
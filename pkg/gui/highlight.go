@@ -0,0 +1,126 @@
+package gui
+
+import (
+	"lazynginx/pkg/nginxconf"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	// Styles of nginx configuration syntax
+	DirectiveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#8BE9FD"))
+	BlockStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF79C6")).Bold(true)
+	VariableStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C"))
+	StringStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C"))
+	CommentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#6272A4")).Italic(true)
+	RegexStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
+)
+
+// variablePattern matches $name and ${name}
+var variablePattern = regexp.MustCompile(`\$(\{\w+\}|\w+)`)
+
+// ConfigStyles returns the style of every byte of nginx configuration
+// source, one slice per line as split by strings.Split(src, "\n"). Unstyled
+// bytes are nil. The source is read with the config lexer, so quoting and
+// comments are taken the way nginx takes them.
+func ConfigStyles(src string) [][]*lipgloss.Style {
+	marks := make([]*lipgloss.Style, len(src))
+	paint := func(start, end int, style *lipgloss.Style) {
+		for i := start; i < end; i++ {
+			marks[i] = style
+		}
+	}
+
+	tokens := nginxconf.Lex(src)
+	statement := true // the next word starts a directive
+	name, arg := "", 0
+	regex := false // the next argument is the regex of a location
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case nginxconf.TokenComment:
+			paint(tok.Start, tok.End, &CommentStyle)
+			continue
+		case nginxconf.TokenSemicolon, nginxconf.TokenOpenBrace, nginxconf.TokenCloseBrace:
+			statement = true
+			continue
+		}
+
+		if statement {
+			style := &DirectiveStyle
+			if opensBlock(tokens[i+1:]) {
+				style = &BlockStyle
+			}
+			paint(tok.Start, tok.End, style)
+			statement, name, arg, regex = false, tok.Value, 0, false
+			continue
+		}
+
+		arg++
+		switch {
+		case name == "location" && arg == 1 && (tok.Value == "~" || tok.Value == "~*"):
+			paint(tok.Start, tok.End, &RegexStyle)
+			regex = true
+			continue
+		case regex:
+			paint(tok.Start, tok.End, &RegexStyle)
+			regex = false
+			continue
+		case tok.Kind == nginxconf.TokenString:
+			paint(tok.Start, tok.End, &StringStyle)
+		}
+		for _, loc := range variablePattern.FindAllStringIndex(src[tok.Start:tok.End], -1) {
+			paint(tok.Start+loc[0], tok.Start+loc[1], &VariableStyle)
+		}
+	}
+
+	var lines [][]*lipgloss.Style
+	offset := 0
+	for _, line := range strings.Split(src, "\n") {
+		lines = append(lines, marks[offset:offset+len(line)])
+		offset += len(line) + 1
+	}
+	return lines
+}
+
+// opensBlock reports whether the statement that tokens continue ends with a brace
+func opensBlock(tokens []nginxconf.Token) bool {
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case nginxconf.TokenOpenBrace:
+			return true
+		case nginxconf.TokenSemicolon, nginxconf.TokenCloseBrace:
+			return false
+		}
+	}
+	return false
+}
+
+// HighlightConfig colors nginx configuration source, returning its lines
+func HighlightConfig(src string) []string {
+	styles := ConfigStyles(src)
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = renderStyled(line, styles[i])
+	}
+	return lines
+}
+
+// renderStyled draws a line with a style per byte, one run of equal styles at a time
+func renderStyled(line string, styles []*lipgloss.Style) string {
+	s := strings.Builder{}
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && styles[end] == styles[start] {
+			end++
+		}
+		if styles[start] == nil {
+			s.WriteString(line[start:end])
+		} else {
+			s.WriteString(styles[start].Render(line[start:end]))
+		}
+		start = end
+	}
+	return s.String()
+}
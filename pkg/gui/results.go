@@ -0,0 +1,175 @@
+package gui
+
+import (
+	"fmt"
+	"lazynginx/pkg/commands"
+	"strings"
+	"time"
+)
+
+// banner heads the status view
+const banner = `
+           _                             _
+          | |                           (_)
+          | | __ _ _____   _ _ __   __ _ _ _ __ __  __
+          | |/ _` + "`" + ` |_  / | | | '_ \ / _` + "`" + ` | | '_ \\ \/ /
+          | | (_| |/ /| |_| | | | | (_| | | | | |>  <
+          |_|\__,_/___|\__, |_| |_|\__, |_|_| |_/_/\_\
+                        __/ |       __/ |
+                       |___/       |___/
+
+`
+
+// RenderStatus renders the status view: the banner, whether nginx runs and
+// the pid and uptime of its master process when known
+func RenderStatus(s commands.Status) string {
+	out := banner + s.Description
+	if s.PID != 0 {
+		out += fmt.Sprintf("\n\nMaster process: %d", s.PID)
+	}
+	if s.Uptime != 0 {
+		out += "\nUptime: " + FormatUptime(s.Uptime)
+	}
+	return out
+}
+
+// FormatUptime renders a duration as "3d 4h 12m", leaving out zero leading units
+func FormatUptime(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	hours := d % (24 * time.Hour) / time.Hour
+	minutes := d % time.Hour / time.Minute
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return d.String()
+}
+
+// TestHeaderLines is how many lines RenderTest writes before the first diagnostic
+const TestHeaderLines = 4
+
+// RenderTest renders the outcome of nginx -t. The errors and warnings are
+// listed first with a cursor, followed by the full nginx output.
+func RenderTest(r commands.TestResult, cursor int) string {
+	if len(r.Diagnostics) == 0 {
+		if r.OK {
+			return "Configuration test passed!\n\n" + r.Output
+		}
+		return "Configuration test failed:\n" + r.Output
+	}
+
+	s := strings.Builder{}
+	if r.OK {
+		s.WriteString("Configuration test passed with warnings:\n")
+	} else {
+		s.WriteString("Configuration test failed:\n")
+	}
+	problems := "problems"
+	if len(r.Diagnostics) == 1 {
+		problems = "problem"
+	}
+	s.WriteString(fmt.Sprintf("%d %s reported by nginx -t\n", len(r.Diagnostics), problems))
+	s.WriteString(strings.Repeat("─", 50) + "\n\n")
+
+	for i, d := range r.Diagnostics {
+		marker := "  "
+		if i == cursor {
+			marker = "▶ "
+		}
+		line := LevelStyle(d.Level).Render("["+d.Level+"]") + " " + d.Message
+		if d.File != "" {
+			line += InfoStyle.Render(fmt.Sprintf("  %s:%d", d.File, d.Line))
+		}
+		s.WriteString(marker + line + "\n")
+	}
+
+	s.WriteString("\nnginx -t output:\n" + r.Output)
+	return s.String()
+}
+
+// RenderService renders the outcome of a service action. A failure lists
+// every method that was tried with what it printed.
+func RenderService(r commands.ServiceResult) string {
+	if r.OK {
+		var done string
+		switch {
+		case r.Done == "kill":
+			done = "Nginx stopped successfully (force killed)"
+		case r.Action == "reload":
+			done = "Nginx configuration reloaded successfully"
+		case r.Done == "reload":
+			done = "Nginx reloaded successfully"
+		default:
+			done = fmt.Sprintf("Nginx %s successfully", pastTense(r.Done))
+		}
+		return done + "\n\n" + r.Output
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Failed to %s nginx. Tried the following methods:\n\n", r.Action)
+	for i, attempt := range r.Attempts {
+		fmt.Fprintf(&b, "%d. %s: %s\n", i+1, attempt.Method, strings.TrimSpace(attempt.Output))
+	}
+	b.WriteString("\nNote: You may need to:\n")
+	b.WriteString("- Run lazynginx with sudo: sudo ./lazynginx\n")
+	b.WriteString("- Or configure passwordless sudo for nginx commands\n")
+	b.WriteString("- Or run as administrator on Windows")
+	return b.String()
+}
+
+// RenderChange renders a change to the configuration files with the
+// nginx -t output, or explains that it was rolled back
+func RenderChange(c commands.Change) string {
+	if c.Test.OK {
+		return fmt.Sprintf("%s\n\nConfiguration test passed:\n%s", DescribeChange(c), c.Test.Output)
+	}
+
+	var out string
+	if c.RollbackErr != nil {
+		out = fmt.Sprintf("⚠️  Configuration test failed and the rollback did not complete!\n\n%s\n\nnginx -t output:\n%s", c.RollbackErr.Error(), c.Test.Output)
+	} else {
+		out = fmt.Sprintf("⚠️  Configuration test failed, the change was rolled back.\n\nnginx -t output:\n%s", c.Test.Output)
+	}
+	if c.Kept != "" {
+		out += "\nYour edited version was saved to: " + c.Kept
+	}
+	return out
+}
+
+// DescribeChange says what a change did, with the next steps it calls for
+func DescribeChange(c commands.Change) string {
+	switch {
+	case c.Action == "edit":
+		return "Saved changes to " + c.Path
+	case c.Kind == "backup":
+		return fmt.Sprintf("Backup %s restored successfully!\n\nRestored:\n- %s", c.Name, strings.Join(c.Paths, "\n- "))
+	case c.Kind == "reverse proxy":
+		return fmt.Sprintf("Reverse proxy '%s' %s successfully!\n\nConfiguration file: %s\n\nType: %s\n\nLocation: %s\nBackend(s): %s\n\nNote: This creates a catch-all server block (server_name _).\nFor production, edit the config to set a specific server_name.", c.Name, pastTense(c.Action), c.Path, c.Type, c.Location, c.Backends)
+	case c.Action == "create" || c.Action == "overwrite":
+		return fmt.Sprintf("Site '%s' %s successfully!\n\nConfiguration file: %s\n\nType: %s\n\nNext steps:\n1. Create directory: %s\n2. Add to /etc/hosts: 127.0.0.1 %s.local", c.Name, pastTense(c.Action), c.Path, c.Type, c.Root, c.Name)
+	case c.Action == "delete":
+		return fmt.Sprintf("Site '%s' deleted successfully!\n\nRemoved: %s\n\nNext steps:\n1. Remove site directory if needed: %s", c.Name, c.Path, c.Root)
+	}
+	return fmt.Sprintf("Site '%s' %s successfully!", c.Name, pastTense(c.Action))
+}
+
+// pastTense turns an action into the word for it being done
+func pastTense(action string) string {
+	switch action {
+	case "start":
+		return "started"
+	case "stop":
+		return "stopped"
+	case "overwrite":
+		return "overwritten"
+	}
+	if strings.HasSuffix(action, "e") {
+		return action + "d"
+	}
+	return action + "ed"
+}
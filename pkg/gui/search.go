@@ -0,0 +1,77 @@
+package gui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	// MatchStyle highlights search matches in the details panel
+	MatchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#1F1F1F")).
+			Background(lipgloss.Color("#F1FA8C"))
+
+	// CurrentMatchStyle highlights the match n/N jumped to
+	CurrentMatchStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#1F1F1F")).
+				Background(lipgloss.Color("#FFB86C")).
+				Bold(true)
+)
+
+// Match is a search hit: the line of the text and the byte range within
+// that line, with color codes removed
+type Match struct {
+	Line       int
+	Start, End int
+}
+
+// CompileSearch turns a search query into a regexp. Plain queries match
+// literally. The search ignores case unless the query has an upper case letter.
+func CompileSearch(query string, regex bool) (*regexp.Regexp, error) {
+	pattern := query
+	if !regex {
+		pattern = regexp.QuoteMeta(query)
+	}
+	if strings.ToLower(query) == query {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// FindMatches returns every match of re in text, in reading order.
+// Empty matches are skipped, so a pattern like "a*" can't match every position.
+func FindMatches(text string, re *regexp.Regexp) []Match {
+	var matches []Match
+	for i, line := range strings.Split(text, "\n") {
+		for _, loc := range re.FindAllStringIndex(ansi.Strip(line), -1) {
+			if loc[1] > loc[0] {
+				matches = append(matches, Match{Line: i, Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+	return matches
+}
+
+// highlightLine draws the matches of one line. Colors of the line itself are
+// dropped so the highlight stays readable. current is the index in matches of
+// the current match, -1 if it isn't on this line.
+func highlightLine(line string, matches []Match, current int) string {
+	plain := ansi.Strip(line)
+
+	s := strings.Builder{}
+	last := 0
+	for i, m := range matches {
+		s.WriteString(plain[last:m.Start])
+		style := MatchStyle
+		if i == current {
+			style = CurrentMatchStyle
+		}
+		s.WriteString(style.Render(plain[m.Start:m.End]))
+		last = m.End
+	}
+	s.WriteString(plain[last:])
+	return s.String()
+}
@@ -1,6 +1,8 @@
 package gui
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -39,6 +41,9 @@ var (
 			Foreground(lipgloss.Color("#FF5555")).
 			Bold(true)
 
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F1FA8C"))
+
 	InfoStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#BD93F9"))
 
@@ -56,3 +61,21 @@ var (
 			Width(60).
 			Height(20)
 )
+
+// RenderDiffLine colors a line of a unified diff: additions green,
+// removals red, hunk headers in the info color. header is set for the two
+// file name lines a diff starts with; later lines starting with "---" or
+// "+++" are a removed "--" or an added "++" line.
+func RenderDiffLine(line string, header bool) string {
+	switch {
+	case header && (strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---")):
+		return InfoStyle.Bold(true).Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return InfoStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return StatusStyle.UnsetBold().Render(line)
+	case strings.HasPrefix(line, "-"):
+		return ErrorStyle.UnsetBold().Render(line)
+	}
+	return line
+}
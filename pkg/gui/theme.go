@@ -0,0 +1,130 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a color palette for the package styles
+type Theme struct {
+	Accent     lipgloss.Color // title background, selected item text
+	Foreground lipgloss.Color // normal text
+	Background lipgloss.Color // selected item background
+	Active     lipgloss.Color // background of the cursor in unfocused panels
+	Focused    lipgloss.Color // border of the focused panel
+	Unfocused  lipgloss.Color // border of the other panels
+	Success    lipgloss.Color
+	Error      lipgloss.Color
+	Warning    lipgloss.Color
+	Info       lipgloss.Color
+
+	// Colors of nginx configuration syntax
+	Directive lipgloss.Color
+	Block     lipgloss.Color // block names: http, server, location...
+	Variable  lipgloss.Color
+	String    lipgloss.Color
+	Comment   lipgloss.Color
+	Regex     lipgloss.Color // regex of a location
+}
+
+// Themes are the palettes selectable with the "theme" setting
+var Themes = map[string]Theme{
+	"default": {
+		Accent:     lipgloss.Color("#7D56F4"),
+		Foreground: lipgloss.Color("#FAFAFA"),
+		Background: lipgloss.Color("#FAFAFA"),
+		Active:     lipgloss.Color("#4A4A4A"),
+		Focused:    lipgloss.Color("2"),
+		Unfocused:  lipgloss.Color("8"),
+		Success:    lipgloss.Color("#50FA7B"),
+		Error:      lipgloss.Color("#FF5555"),
+		Warning:    lipgloss.Color("#F1FA8C"),
+		Info:       lipgloss.Color("#BD93F9"),
+
+		Directive: lipgloss.Color("#8BE9FD"),
+		Block:     lipgloss.Color("#FF79C6"),
+		Variable:  lipgloss.Color("#FFB86C"),
+		String:    lipgloss.Color("#F1FA8C"),
+		Comment:   lipgloss.Color("#6272A4"),
+		Regex:     lipgloss.Color("#50FA7B"),
+	},
+	"light": {
+		Accent:     lipgloss.Color("#5A3FC0"),
+		Foreground: lipgloss.Color("#1F1F1F"),
+		Background: lipgloss.Color("#E8E8E8"),
+		Active:     lipgloss.Color("#C8C8C8"),
+		Focused:    lipgloss.Color("#2E7D32"),
+		Unfocused:  lipgloss.Color("#9E9E9E"),
+		Success:    lipgloss.Color("#2E7D32"),
+		Error:      lipgloss.Color("#C62828"),
+		Warning:    lipgloss.Color("#B26A00"),
+		Info:       lipgloss.Color("#5A3FC0"),
+
+		Directive: lipgloss.Color("#0277BD"),
+		Block:     lipgloss.Color("#AD1457"),
+		Variable:  lipgloss.Color("#E65100"),
+		String:    lipgloss.Color("#6D6A00"),
+		Comment:   lipgloss.Color("#757575"),
+		Regex:     lipgloss.Color("#2E7D32"),
+	},
+	"monochrome": {
+		Accent:     lipgloss.Color("0"),
+		Foreground: lipgloss.Color("7"),
+		Background: lipgloss.Color("7"),
+		Active:     lipgloss.Color("8"),
+		Focused:    lipgloss.Color("15"),
+		Unfocused:  lipgloss.Color("8"),
+		Success:    lipgloss.Color("15"),
+		Error:      lipgloss.Color("15"),
+		Warning:    lipgloss.Color("15"),
+		Info:       lipgloss.Color("7"),
+
+		Directive: lipgloss.Color("15"),
+		Block:     lipgloss.Color("15"),
+		Variable:  lipgloss.Color("7"),
+		String:    lipgloss.Color("7"),
+		Comment:   lipgloss.Color("8"),
+		Regex:     lipgloss.Color("7"),
+	},
+}
+
+// ApplyTheme rebuilds the package styles from the named theme. An unknown
+// name is an error, and the default theme is applied instead.
+func ApplyTheme(name string) error {
+	var err error
+	theme, ok := Themes[name]
+	if !ok {
+		names := make([]string, 0, len(Themes))
+		for known := range Themes {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		err = fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+		theme = Themes["default"]
+	}
+
+	FocusedBorderColor = theme.Focused
+	UnfocusedBorderColor = theme.Unfocused
+
+	TitleStyle = TitleStyle.Foreground(theme.Foreground).Background(theme.Accent)
+	SelectedStyle = SelectedStyle.Foreground(theme.Accent).Background(theme.Background)
+	ActiveStyle = ActiveStyle.Foreground(theme.Foreground).Background(theme.Active)
+	NormalStyle = NormalStyle.Foreground(theme.Foreground)
+	StatusStyle = StatusStyle.Foreground(theme.Success)
+	ErrorStyle = ErrorStyle.Foreground(theme.Error)
+	WarningStyle = WarningStyle.Foreground(theme.Warning)
+	InfoStyle = InfoStyle.Foreground(theme.Info)
+
+	DirectiveStyle = DirectiveStyle.Foreground(theme.Directive)
+	BlockStyle = BlockStyle.Foreground(theme.Block)
+	VariableStyle = VariableStyle.Foreground(theme.Variable)
+	StringStyle = StringStyle.Foreground(theme.String)
+	CommentStyle = CommentStyle.Foreground(theme.Comment)
+	RegexStyle = RegexStyle.Foreground(theme.Regex)
+	GutterStyle = GutterStyle.Foreground(theme.Unfocused)
+	FoldStyle = FoldStyle.Foreground(theme.Info)
+	return err
+}
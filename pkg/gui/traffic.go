@@ -0,0 +1,167 @@
+package gui
+
+import (
+	"fmt"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// sparkBlocks are the sparkline levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of block characters scaled to the largest value
+func Sparkline(values []int) string {
+	highest := 0
+	for _, v := range values {
+		highest = utils.Max(highest, v)
+	}
+
+	s := strings.Builder{}
+	for _, v := range values {
+		if highest == 0 || v == 0 {
+			s.WriteRune(' ')
+			continue
+		}
+		level := v * (len(sparkBlocks) - 1) / highest
+		s.WriteRune(sparkBlocks[level])
+	}
+	return s.String()
+}
+
+// RenderTraffic renders the Traffic view of the access log statistics.
+// width is the room available in the details panel.
+func RenderTraffic(stats logs.Stats, path string, format string, skipped int, width int) string {
+	width = utils.Max(width, 40)
+
+	s := strings.Builder{}
+	s.WriteString(TitleStyle.Render(fmt.Sprintf(" Traffic - last %s ", formatWindow(stats.Window))) + "\n\n")
+	s.WriteString(fmt.Sprintf("Access log: %s (format %s)\n", path, format))
+	s.WriteString(fmt.Sprintf("%s to %s\n", stats.From.Format("2006-01-02 15:04:05"), stats.To.Format("15:04:05")))
+	if skipped > 0 {
+		s.WriteString(InfoStyle.Render(fmt.Sprintf("%d lines did not match the log format and were skipped", skipped)) + "\n")
+	}
+	if stats.Span < stats.Window {
+		s.WriteString(InfoStyle.Render(fmt.Sprintf("Only the last %s of the log was read; the rate covers that period", formatWindow(stats.Span))) + "\n")
+	}
+	s.WriteString("\n")
+
+	s.WriteString(fmt.Sprintf("Requests: %d    Rate: %.2f req/s\n", stats.Total, stats.PerSec))
+	s.WriteString(StatusStyle.Render(Sparkline(stats.Buckets)) + "\n")
+	s.WriteString(InfoStyle.Render(fmt.Sprintf("%-*s%s", utils.Max(len(stats.Buckets)-3, 0), "-"+formatWindow(stats.Window), "now")) + "\n\n")
+
+	if stats.Total == 0 {
+		s.WriteString("No requests in this window.\n")
+		return s.String()
+	}
+
+	// Status classes
+	statusRows := [][]string{}
+	for class := 2; class <= 5; class++ {
+		count := stats.Classes[class]
+		statusRows = append(statusRows, []string{fmt.Sprintf("%dxx", class), fmt.Sprint(count), percent(count, stats.Total)})
+	}
+	s.WriteString("Status codes\n")
+	s.WriteString(newTable().
+		Headers("Status", "Requests", "%").
+		Rows(statusRows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if row == table.HeaderRow {
+				return style.Bold(true)
+			}
+			switch row {
+			case 0:
+				return style.Foreground(StatusStyle.GetForeground())
+			case 3:
+				return style.Foreground(ErrorStyle.GetForeground())
+			}
+			return style
+		}).
+		Render() + "\n\n")
+
+	// Latency
+	if stats.Latency.Samples > 0 || stats.Upstream.Samples > 0 {
+		s.WriteString("Latency\n")
+		latency := newTable().Headers("", "$request_time", "$upstream_response_time")
+		rows := []struct {
+			name    string
+			request time.Duration
+			up      time.Duration
+		}{
+			{"p50", stats.Latency.P50, stats.Upstream.P50},
+			{"p90", stats.Latency.P90, stats.Upstream.P90},
+			{"p95", stats.Latency.P95, stats.Upstream.P95},
+			{"p99", stats.Latency.P99, stats.Upstream.P99},
+			{"max", stats.Latency.Max, stats.Upstream.Max},
+		}
+		for _, row := range rows {
+			latency.Row(row.name, formatLatency(row.request, stats.Latency.Samples), formatLatency(row.up, stats.Upstream.Samples))
+		}
+		s.WriteString(latency.Render() + "\n\n")
+	} else {
+		s.WriteString(InfoStyle.Render("Latency: the log format has no $request_time or $upstream_response_time") + "\n\n")
+	}
+
+	s.WriteString(renderTop("Top paths", "Path", stats.Paths, stats.Total, width) + "\n\n")
+	s.WriteString(renderTop("Top client IPs", "IP", stats.IPs, stats.Total, width) + "\n\n")
+	s.WriteString(renderTop("Top user agents", "User agent", stats.Agents, stats.Total, width) + "\n")
+
+	return s.String()
+}
+
+// newTable returns a table in the package style
+func newTable() *table.Table {
+	return table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(UnfocusedBorderColor)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if row == table.HeaderRow {
+				return style.Bold(true)
+			}
+			return style
+		})
+}
+
+// renderTop renders a top-N table, shortening values to fit in width
+func renderTop(title string, header string, counts []logs.Count, total int, width int) string {
+	// Room left for the value after the count columns and borders
+	valueWidth := utils.Max(width-30, 10)
+
+	t := newTable().Headers(header, "Requests", "%")
+	for _, c := range counts {
+		t.Row(ansi.Truncate(c.Value, valueWidth, "..."), fmt.Sprint(c.Count), percent(c.Count, total))
+	}
+	return title + "\n" + t.Render()
+}
+
+func percent(count int, total int) string {
+	if total == 0 {
+		return "0.0"
+	}
+	return fmt.Sprintf("%.1f", float64(count)*100/float64(total))
+}
+
+func formatLatency(d time.Duration, samples int) string {
+	if samples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
+
+// formatWindow renders a window as "5m", "1h" or "24h"
+func formatWindow(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.Round(time.Second).String()
+}
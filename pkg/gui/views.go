@@ -1,10 +1,16 @@
 package gui
 
 import (
+	"fmt"
+	"lazynginx/pkg/logs"
+	"lazynginx/pkg/textedit"
 	"lazynginx/pkg/utils"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/jesseduffield/lazycore/pkg/boxlayout"
 )
 
@@ -27,6 +33,17 @@ type ModelView interface {
 	GetMainScroll() int
 	GetSubScroll() int
 	GetDetailScroll() int
+	GetSiteState(siteName string) (enabled bool, known bool)
+	GetPreview() (path string, preview string, overwrite bool)
+	GetModalScroll() int
+	GetFollowing() (following bool, paused bool)
+	GetErrorLogView() (shown bool, level string, grouped bool)
+	GetSearch() (query string, regex bool, current int, typing bool)
+	GetLogFilter() (filter string, typing bool, status string)
+	GetLogFiles() []logs.LogFile
+	GetSiteLog() string
+	GetTestView() (shown bool, hasFile bool)
+	GetEditor() (buffer *textedit.Buffer, path string, note string)
 }
 
 func ViewMainMenuWithDim(m ModelView, dim boxlayout.Dimensions) string {
@@ -234,6 +251,16 @@ func ViewSubMenuWithDim(m ModelView, dim boxlayout.Dimensions) string {
 	for idx, i := range make([]int, endLine-startLine) {
 		i = startLine + idx
 		choice := subItems[i]
+		// Sites show whether they are enabled (●) or disabled (○)
+		if mainCursor == 2 {
+			if enabled, known := m.GetSiteState(choice); known {
+				if enabled {
+					choice = "● " + choice
+				} else {
+					choice = "○ " + choice
+				}
+			}
+		}
 		cursor := "  "
 		var line string
 		if subCursor == i && activePanel == 1 {
@@ -318,6 +345,11 @@ func ViewSubMenuWithDim(m ModelView, dim boxlayout.Dimensions) string {
 }
 
 func ViewDetailsWithDim(m ModelView, dim boxlayout.Dimensions) string {
+	// The built-in editor takes the whole panel while open
+	if buffer, path, note := m.GetEditor(); buffer != nil {
+		return viewEditorWithDim(m, dim, buffer, path, note)
+	}
+
 	// Calculate dimensions from the box
 	boxWidth := dim.X1 - dim.X0 + 1
 	boxHeight := dim.Y1 - dim.Y0 + 1
@@ -332,6 +364,20 @@ func ViewDetailsWithDim(m ModelView, dim boxlayout.Dimensions) string {
 	// Split content into lines for scrolling
 	contentLines := strings.Split(detailOutput, "\n")
 
+	// Search matches per line, and which match of its line is the current one
+	lineMatches := map[int][]Match{}
+	currentInLine := map[int]int{}
+	if query, regex, current, _ := m.GetSearch(); query != "" {
+		if re, err := CompileSearch(query, regex); err == nil {
+			for i, match := range FindMatches(detailOutput, re) {
+				if i == current {
+					currentInLine[match.Line] = len(lineMatches[match.Line])
+				}
+				lineMatches[match.Line] = append(lineMatches[match.Line], match)
+			}
+		}
+	}
+
 	// Calculate available height for content
 	// Border takes 2 lines (top + bottom), content already includes padding
 	contentHeight := boxHeight - 2
@@ -385,13 +431,20 @@ func ViewDetailsWithDim(m ModelView, dim boxlayout.Dimensions) string {
 	for idx := 0; idx < endLine-startLine; idx++ {
 		line := contentLines[startLine+idx]
 
+		if matches, ok := lineMatches[startLine+idx]; ok {
+			current, isCurrent := currentInLine[startLine+idx]
+			if !isCurrent {
+				current = -1
+			}
+			line = highlightLine(line, matches, current)
+		}
+
 		// Replace tabs with spaces for consistent rendering
 		line = strings.ReplaceAll(line, "\t", "    ")
 
-		// Truncate the line to contentWidth
-		runes := []rune(line)
-		if len(runes) > contentWidth-3 {
-			line = string(runes[:contentWidth-3]) + "..."
+		// Truncate the line to contentWidth; colored lines keep their escape codes intact
+		if ansi.StringWidth(line) > contentWidth-3 {
+			line = ansi.Truncate(line, contentWidth-3, "") + "..."
 		}
 
 		s.WriteString(line + "\n")
@@ -477,27 +530,70 @@ func ViewFooter(m ModelView, windowWidth int) string {
 	case 0: // Main menu
 		// Show [e] edit for Configuration menu (has no submenu, editable from main menu)
 		if mainCursor == 4 {
-			keybindings = "[↑↓/jk] scroll [→/l/tab] next panel [enter] select [e] edit [mouse] scroll/click [q] quit"
+			keybindings = "[↑↓/jk] scroll [→/l/tab] next panel [enter] select [e] edit [E] edit inline [mouse] scroll/click [q] quit"
 		} else {
 			keybindings = "[↑↓/jk] scroll [→/l/tab] next panel [enter] select [mouse] scroll/click [q] quit"
 		}
 	case 1: // Sub menu
 		// Check if we're in Sites menu with a site selected (not "Add site")
 		if mainCursor == 2 && subCursor > 0 {
-			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] execute [e] edit [d] delete [mouse] scroll/click [q] quit"
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] execute [e] edit [E] edit inline [space] enable/disable [d] delete [L] logs " + siteLogKeys(m) + "[mouse] scroll/click [q] quit"
 		} else if mainCursor == 4 {
-			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] execute [e] edit [mouse] scroll/click [q] quit"
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] execute [e] edit [E] edit inline [mouse] scroll/click [q] quit"
+		} else if mainCursor == 0 && subCursor == 2 {
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] refresh [w] time window [mouse] scroll/click [q] quit"
+		} else if mainCursor == 5 {
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] execute " + errorLogKeys(m) + filterKeys(m) + followKeys(m) + " [o] open rotated [mouse] scroll/click [q] quit"
+		} else if mainCursor == 6 {
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] restore [mouse] scroll/click [q] quit"
 		} else {
 			keybindings = "[↑↓/jk] scroll [←/h] prev panel [→/l/tab] next panel [enter] execute [mouse] scroll/click [q] quit"
 		}
 	case 2: // Details
-		if m.GetCurrentConfigPath() != "" {
-			keybindings = "[↑↓/jk] scroll [←/h] prev panel [e] edit [mouse] scroll/click [q] quit"
+		if mainCursor == 0 && subCursor == 2 {
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel [w] time window [mouse] scroll/click [q] quit"
+		} else if shown, _, _ := m.GetErrorLogView(); shown && mainCursor == 2 {
+			keybindings = "[↑↓/jk] select [←/h] prev panel [enter] open upstream config " + errorLogKeys(m) + followKeys(m) + " [L] logs [mouse] scroll/click [q] quit"
+		} else if shown {
+			keybindings = "[↑↓/jk] select [←/h] prev panel [enter] open upstream config " + errorLogKeys(m) + followKeys(m) + " [o] open rotated [mouse] scroll/click [q] quit"
+		} else if shown, hasFile := m.GetTestView(); shown {
+			keybindings = "[↑↓/jk] select [←/h] prev panel "
+			if hasFile {
+				keybindings += "[enter] open at line [e/E] edit at line "
+			}
+			keybindings += "[mouse] scroll/click [q] quit"
+		} else if m.GetCurrentConfigPath() != "" {
+			keybindings = "[↑↓/jk] move [←/h] prev panel [e] edit [E] edit inline [za/zo/zc] fold [zM/zR] fold/unfold all [#] line numbers [mouse] scroll/click [q] quit"
+		} else if mainCursor == 2 && m.GetSiteLog() != "" {
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel " + filterKeys(m) + followKeys(m) + " [L] logs [mouse] scroll/click [q] quit"
+		} else if mainCursor == 5 {
+			keybindings = "[↑↓/jk] scroll [←/h] prev panel " + filterKeys(m) + followKeys(m) + " [o] open rotated [mouse] scroll/click [q] quit"
 		} else {
 			keybindings = "[↑↓/jk] scroll [←/h] prev panel [mouse] scroll/click [q] quit"
 		}
 	}
 
+	if activePanel == 2 {
+		keybindings = searchKeys(m) + keybindings
+	}
+
+	// The built-in editor takes every key while open
+	if buffer, _, _ := m.GetEditor(); buffer != nil {
+		keybindings = "[ctrl+s] save and test [ctrl+z] undo [ctrl+y] redo [home/end] line start/end [ctrl+home/end] top/bottom [esc] close"
+	}
+
+	// The filter and search prompts replace the keybindings while typing
+	if filter, typing, status := m.GetLogFilter(); typing {
+		keybindings = fmt.Sprintf("filter: %s▏ (%s) [enter] done [esc] cancel [ctrl+u] clear", filter, status)
+	}
+	if query, regex, _, typing := m.GetSearch(); typing {
+		mode := "text"
+		if regex {
+			mode = "regex"
+		}
+		keybindings = fmt.Sprintf("/%s▏ (%s, %s) [enter] done [esc] cancel [ctrl+r] text/regex", query, mode, describeMatches(m))
+	}
+
 	footerStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#1E3A8A")).
 		Width(windowWidth).
@@ -507,8 +603,111 @@ func ViewFooter(m ModelView, windowWidth int) string {
 	return footerStyle.Render(keybindings)
 }
 
+// errorLogKeys returns the severity filter and grouping keybindings while the error log list is shown
+func errorLogKeys(m ModelView) string {
+	shown, level, grouped := m.GetErrorLogView()
+	if !shown {
+		return ""
+	}
+	if level == "" {
+		level = "all"
+	} else {
+		level += "+"
+	}
+	group := "[g] group"
+	if grouped {
+		group = "[g] ungroup"
+	}
+	return fmt.Sprintf("[s] severity (%s) %s ", level, group)
+}
+
+// siteLogKeys returns the keybindings of the site log shown in the details panel
+func siteLogKeys(m ModelView) string {
+	if m.GetSiteLog() == "" {
+		return ""
+	}
+	return errorLogKeys(m) + filterKeys(m) + followKeys(m) + " "
+}
+
+// searchKeys returns the search keybindings of the details panel
+func searchKeys(m ModelView) string {
+	query, _, _, _ := m.GetSearch()
+	if query == "" {
+		return "[/] search "
+	}
+	return fmt.Sprintf("/%s (%s) [n/N] next/prev [esc] clear ", query, describeMatches(m))
+}
+
+// describeMatches tells the position of the current match, e.g. "3/17"
+func describeMatches(m ModelView) string {
+	query, regex, current, _ := m.GetSearch()
+	if query == "" {
+		return "type to search"
+	}
+	re, err := CompileSearch(query, regex)
+	if err != nil {
+		return "invalid regex"
+	}
+	matches := FindMatches(m.GetDetailOutput(), re)
+	if len(matches) == 0 {
+		return "no matches"
+	}
+	return fmt.Sprintf("%d/%d", utils.Min(current, len(matches)-1)+1, len(matches))
+}
+
+// filterKeys returns the access log filter keybinding, with the filter in use
+func filterKeys(m ModelView) string {
+	filter, _, status := m.GetLogFilter()
+	if status == "" {
+		return ""
+	}
+	if filter == "" {
+		return "[F] filter "
+	}
+	return fmt.Sprintf("[F] filter: %s (%s) ", filter, status)
+}
+
+// describeLogFile renders a picker entry: name, the time span it covers and its size
+func describeLogFile(f logs.LogFile) string {
+	from := "..."
+	if !f.From.IsZero() {
+		from = f.From.Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("%-24s %16s → %-16s %8s", filepath.Base(f.Path), from, f.ModTime.Format("2006-01-02 15:04"), formatSize(f.Size))
+}
+
+// formatSize renders a byte count as B, KB, MB or GB
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, suffix := float64(size)/unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// followKeys returns the log follow keybindings for the current follow state
+func followKeys(m ModelView) string {
+	following, paused := m.GetFollowing()
+	if !following {
+		return "[f] follow"
+	}
+	if paused {
+		return "[f] stop following [p] resume"
+	}
+	return "[f] stop following [p] pause"
+}
+
 func ViewModal(m ModelView) string {
 	var content string
+	modalWidth := 50
+	modalAlign := lipgloss.Center
 
 	modalType := m.GetModalType()
 	modalCursor := m.GetModalCursor()
@@ -557,6 +756,196 @@ func ViewModal(m ModelView) string {
 		s.WriteString("\n")
 		s.WriteString(InfoStyle.Render("↑/↓: Navigate | Enter: Confirm | Esc: Cancel") + "\n")
 		content = s.String()
+	} else if modalType == "confirm-reload" {
+		title := " Reload Nginx "
+		options := []string{"Yes", "No"}
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString("Configuration test passed.\n")
+		s.WriteString("Reload nginx now to apply the change?\n\n")
+
+		for i, opt := range options {
+			cursor := "  "
+			if modalCursor == i {
+				cursor = "▶ "
+				s.WriteString(SelectedStyle.Render(cursor+opt) + "\n")
+			} else {
+				s.WriteString(NormalStyle.Render(cursor+opt) + "\n")
+			}
+		}
+
+		s.WriteString("\n")
+		s.WriteString(InfoStyle.Render("↑/↓: Navigate | Enter: Confirm | Esc: Cancel") + "\n")
+		content = s.String()
+	} else if modalType == "confirm-discard" {
+		title := " Discard Changes "
+		options := []string{"Yes", "No"}
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString("The file has unsaved changes.\n")
+		s.WriteString("Close the editor and discard them?\n\n")
+
+		for i, opt := range options {
+			cursor := "  "
+			if modalCursor == i {
+				cursor = "▶ "
+				s.WriteString(SelectedStyle.Render(cursor+opt) + "\n")
+			} else {
+				s.WriteString(NormalStyle.Render(cursor+opt) + "\n")
+			}
+		}
+
+		s.WriteString("\n")
+		s.WriteString(InfoStyle.Render("↑/↓: Navigate | Enter: Confirm | Esc: Cancel") + "\n")
+		content = s.String()
+	} else if modalType == "confirm-restore" {
+		title := " Restore Backup "
+		options := []string{"Yes", "No"}
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString("Restore the files in this backup?\n")
+		s.WriteString("The current files are backed up first.\n\n")
+
+		for i, opt := range options {
+			cursor := "  "
+			if modalCursor == i {
+				cursor = "▶ "
+				s.WriteString(SelectedStyle.Render(cursor+opt) + "\n")
+			} else {
+				s.WriteString(NormalStyle.Render(cursor+opt) + "\n")
+			}
+		}
+
+		s.WriteString("\n")
+		s.WriteString(InfoStyle.Render("↑/↓: Navigate | Enter: Confirm | Esc: Cancel") + "\n")
+		content = s.String()
+	} else if modalType == "confirm-write" {
+		path, preview, overwrite := m.GetPreview()
+		title := " Preview New File "
+		question := "Write this file?"
+		if overwrite {
+			title = " Overwrite Existing File "
+			question = "The file already exists. Overwrite it with these changes?"
+		}
+		options := []string{"Yes", "No"}
+
+		// The preview needs more room than the other modals
+		modalWidth = utils.Min(utils.Max(m.GetWindowWidth()-8, 50), 100)
+		modalAlign = lipgloss.Left
+		lineWidth := modalWidth - 5
+
+		lines := strings.Split(strings.TrimSuffix(preview, "\n"), "\n")
+		visibleLines := utils.Max(m.GetWindowHeight()-16, 5)
+		start := utils.Max(utils.Min(m.GetModalScroll(), len(lines)-visibleLines), 0)
+		end := utils.Min(start+visibleLines, len(lines))
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString(InfoStyle.Render(path) + "\n\n")
+		for i, line := range lines[start:end] {
+			runes := []rune(line)
+			if len(runes) > lineWidth {
+				line = string(runes[:lineWidth-3]) + "..."
+			}
+			if overwrite {
+				line = RenderDiffLine(line, start+i < 2)
+			}
+			s.WriteString(line + "\n")
+		}
+		if len(lines) > visibleLines {
+			s.WriteString(InfoStyle.Render(fmt.Sprintf("lines %d-%d of %d", start+1, end, len(lines))) + "\n")
+		}
+		s.WriteString("\n" + question + "\n\n")
+
+		for i, opt := range options {
+			cursor := "  "
+			if modalCursor == i {
+				cursor = "▶ "
+				s.WriteString(SelectedStyle.Render(cursor+opt) + "\n")
+			} else {
+				s.WriteString(NormalStyle.Render(cursor+opt) + "\n")
+			}
+		}
+
+		s.WriteString("\n")
+		s.WriteString(InfoStyle.Render("↑/↓: Navigate | PgUp/PgDn: Scroll | Enter: Confirm | Esc: Cancel") + "\n")
+		content = s.String()
+	} else if modalType == "log-file" {
+		title := " Open Log File "
+		files := m.GetLogFiles()
+		modalWidth = utils.Min(utils.Max(m.GetWindowWidth()-8, 50), 90)
+
+		// Keep the cursor in view when logrotate kept many files
+		visibleLines := utils.Max(m.GetWindowHeight()-14, 3)
+		start := utils.Max(utils.Min(modalCursor-visibleLines/2, len(files)-visibleLines), 0)
+		end := utils.Min(start+visibleLines, len(files))
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString("Select the log or a rotated copy to view:\n\n")
+
+		for i := start; i < end; i++ {
+			cursor := "  "
+			opt := describeLogFile(files[i])
+			if modalCursor == i {
+				cursor = "▶ "
+				s.WriteString(SelectedStyle.Render(cursor+opt) + "\n")
+			} else {
+				s.WriteString(NormalStyle.Render(cursor+opt) + "\n")
+			}
+		}
+
+		s.WriteString("\n")
+		s.WriteString(InfoStyle.Render("↑/↓: Navigate | Enter: View | t: Time span | Esc: Cancel") + "\n")
+		content = s.String()
+	} else if modalType == "log-span" {
+		title := " Time Span "
+		file := ""
+		if files := m.GetLogFiles(); modalCursor < len(files) {
+			file = describeLogFile(files[modalCursor])
+		}
+		modalWidth = utils.Min(utils.Max(m.GetWindowWidth()-8, 50), 90)
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString(file + "\n\n")
+		s.WriteString("Lines written in a time span, e.g. since=2026-01-02T09:00 until=2026-01-02T11:30:\n\n")
+		s.WriteString(SelectedStyle.Render(" "+textInput+"█ ") + "\n")
+		if _, err := logs.ParseSpan(textInput, time.Now()); err != nil && textInput != "" {
+			s.WriteString(ErrorStyle.Render(err.Error()) + "\n")
+		} else {
+			s.WriteString("\n")
+		}
+		s.WriteString("\n" + InfoStyle.Render("Type a time span | Enter: View | Esc: Cancel") + "\n")
+		content = s.String()
+	} else if modalType == "site-logs" {
+		title := " Site Logs "
+		options := []string{"View site access log", "View site error log"}
+		site := ""
+		if subItems := m.GetSubMenus()[m.GetMainCursor()]; m.GetSubCursor() < len(subItems) {
+			site = subItems[m.GetSubCursor()]
+		}
+
+		s := strings.Builder{}
+		s.WriteString(TitleStyle.Render(title) + "\n\n")
+		s.WriteString(fmt.Sprintf("Logs of %s:\n\n", site))
+
+		for i, opt := range options {
+			cursor := "  "
+			if modalCursor == i {
+				cursor = "▶ "
+				s.WriteString(SelectedStyle.Render(cursor+opt) + "\n")
+			} else {
+				s.WriteString(NormalStyle.Render(cursor+opt) + "\n")
+			}
+		}
+
+		s.WriteString("\n")
+		s.WriteString(InfoStyle.Render("↑/↓: Navigate | Enter: View | Esc: Cancel") + "\n")
+		content = s.String()
 	} else if modalType == "site-type" {
 		title := " Add New Site "
 		options := []string{"Laravel", "Static Website", "Vanilla PHP", "Custom"}
@@ -681,8 +1070,8 @@ func ViewModal(m ModelView) string {
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(lipgloss.Color("#FF79C6")).
 		Padding(1, 2).
-		Width(50).
-		AlignHorizontal(lipgloss.Center)
+		Width(modalWidth).
+		AlignHorizontal(modalAlign)
 
 	return modalStyle.Render(content)
 }
@@ -0,0 +1,292 @@
+package logs
+
+import (
+	"fmt"
+	"lazynginx/pkg/nginxconf"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Combined is nginx's predefined log format, used when access_log names no format
+const Combined = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+// Format is a compiled log_format
+type Format struct {
+	Name    string
+	Pattern string   // the log_format string, e.g. Combined
+	fields  []string // variable names in the order they appear
+	re      *regexp.Regexp
+}
+
+// Record is an access log line split into fields
+type Record struct {
+	Raw    string
+	Fields map[string]string // every variable of the format, without the "$"
+
+	RemoteAddr string
+	RemoteUser string
+	Time       time.Time // zero if the format has no time variable
+	Method     string
+	URI        string // request URI with the query string
+	Path       string // request URI without the query string
+	Protocol   string
+	Status     int
+	BytesSent  int64
+	Referer    string
+	UserAgent  string
+	Host       string
+
+	// $request_time and $upstream_response_time; the Has* fields tell
+	// whether the line carried a value
+	RequestTime             time.Duration
+	HasRequestTime          bool
+	UpstreamResponseTime    time.Duration
+	HasUpstreamResponseTime bool
+}
+
+// variablePattern matches $name and ${name} in a log_format string
+var variablePattern = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+
+// CompileFormat turns a log_format string into a Format.
+// Each variable matches up to the literal character that follows it.
+func CompileFormat(name string, pattern string) (*Format, error) {
+	f := &Format{Name: name, Pattern: pattern}
+
+	var re strings.Builder
+	re.WriteString("^")
+	matches := variablePattern.FindAllStringSubmatchIndex(pattern, -1)
+	last := 0
+	for i, m := range matches {
+		re.WriteString(regexp.QuoteMeta(pattern[last:m[0]]))
+
+		var variable string
+		if m[2] >= 0 {
+			variable = pattern[m[2]:m[3]] // ${name}
+		} else {
+			variable = pattern[m[4]:m[5]] // $name
+		}
+		f.fields = append(f.fields, variable)
+
+		// The literal text after the variable decides where its value ends
+		next := len(pattern)
+		if i+1 < len(matches) {
+			next = matches[i+1][0]
+		}
+		literal := pattern[m[1]:next]
+		switch {
+		case literal == "" && next == len(pattern):
+			re.WriteString("(.*)")
+		case literal == "":
+			// Two variables without a separator; the split is a guess
+			re.WriteString("(.*?)")
+		case literal[0] == '"':
+			// Quoted values escape embedded quotes (\x22, or \" with escape=json)
+			re.WriteString(`((?:[^"\\]|\\.)*)`)
+		default:
+			re.WriteString("([^" + regexp.QuoteMeta(literal[:1]) + "]*)")
+		}
+		last = m[1]
+	}
+	re.WriteString(regexp.QuoteMeta(pattern[last:]))
+	re.WriteString("$")
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil, fmt.Errorf("log_format %s: %v", name, err)
+	}
+	f.re = compiled
+	return f, nil
+}
+
+// Fields returns the variable names of the format, without the "$"
+func (f *Format) Fields() []string {
+	return f.fields
+}
+
+// Has reports whether the format logs the given variable
+func (f *Format) Has(variable string) bool {
+	for _, field := range f.fields {
+		if field == variable {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse splits a log line into a Record. ok is false if the line doesn't match the format.
+func (f *Format) Parse(line string) (record *Record, ok bool) {
+	values := f.re.FindStringSubmatch(line)
+	if values == nil {
+		return nil, false
+	}
+
+	r := &Record{Raw: line, Fields: make(map[string]string, len(f.fields))}
+	for i, field := range f.fields {
+		r.Fields[field] = unescape(values[i+1])
+	}
+	r.fill()
+	return r, true
+}
+
+// unescape undoes nginx's log escaping: \xHH (escape=default) and \" or \\ (escape=json)
+func unescape(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			switch next := value[i+1]; {
+			case next == 'x' && i+3 < len(value):
+				if b, err := strconv.ParseUint(value[i+2:i+4], 16, 8); err == nil {
+					out.WriteByte(byte(b))
+					i += 3
+					continue
+				}
+			case next == '"' || next == '\\':
+				out.WriteByte(next)
+				i++
+				continue
+			}
+		}
+		out.WriteByte(value[i])
+	}
+	return out.String()
+}
+
+// fill sets the typed fields from the raw variable values
+func (r *Record) fill() {
+	get := func(name string) string {
+		value := r.Fields[name]
+		if value == "-" {
+			return ""
+		}
+		return value
+	}
+
+	r.RemoteAddr = get("remote_addr")
+	r.RemoteUser = get("remote_user")
+	r.Referer = get("http_referer")
+	r.UserAgent = get("http_user_agent")
+	r.Host = get("host")
+	if r.Host == "" {
+		r.Host = get("server_name")
+	}
+	r.Status, _ = strconv.Atoi(get("status"))
+
+	if bytes := get("body_bytes_sent"); bytes != "" {
+		r.BytesSent, _ = strconv.ParseInt(bytes, 10, 64)
+	} else if bytes := get("bytes_sent"); bytes != "" {
+		r.BytesSent, _ = strconv.ParseInt(bytes, 10, 64)
+	}
+
+	switch {
+	case get("time_local") != "":
+		r.Time, _ = time.Parse("02/Jan/2006:15:04:05 -0700", get("time_local"))
+	case get("time_iso8601") != "":
+		r.Time, _ = time.Parse(time.RFC3339, get("time_iso8601"))
+	case get("msec") != "":
+		if msec, err := strconv.ParseFloat(get("msec"), 64); err == nil {
+			r.Time = time.UnixMilli(int64(msec * 1000))
+		}
+	}
+
+	// "$request" is "METHOD URI PROTOCOL"; the separate variables win when logged
+	if parts := strings.Fields(get("request")); len(parts) >= 2 {
+		r.Method, r.URI = parts[0], parts[1]
+		if len(parts) >= 3 {
+			r.Protocol = parts[2]
+		}
+	}
+	if method := get("request_method"); method != "" {
+		r.Method = method
+	}
+	if uri := get("request_uri"); uri != "" {
+		r.URI = uri
+	}
+	if protocol := get("server_protocol"); protocol != "" {
+		r.Protocol = protocol
+	}
+	r.Path, _, _ = strings.Cut(r.URI, "?")
+	if uri := get("uri"); uri != "" && r.Path == "" {
+		r.Path = uri
+	}
+
+	r.RequestTime, r.HasRequestTime = parseSeconds(get("request_time"))
+	r.UpstreamResponseTime, r.HasUpstreamResponseTime = parseSeconds(get("upstream_response_time"))
+}
+
+// parseSeconds parses nginx times like "0.123". Several upstreams tried for
+// one request are logged as "0.010, 0.020" (or "0.010 : 0.020") and summed.
+func parseSeconds(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var total time.Duration
+	found := false
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ':' || r == ' ' }) {
+		seconds, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			continue
+		}
+		total += time.Duration(seconds * float64(time.Second))
+		found = true
+	}
+	return total, found
+}
+
+// Formats returns the log formats defined with log_format in directives,
+// plus the predefined combined format
+func Formats(directives []*nginxconf.Directive) map[string]*Format {
+	formats := map[string]*Format{}
+	if combined, err := CompileFormat("combined", Combined); err == nil {
+		formats["combined"] = combined
+	}
+
+	for _, d := range nginxconf.Find(directives, "log_format") {
+		if len(d.Args) < 2 {
+			continue
+		}
+		// log_format name [escape=default|json|none] string ...;
+		parts := d.Args[1:]
+		if strings.HasPrefix(parts[0], "escape=") {
+			parts = parts[1:]
+		}
+		if format, err := CompileFormat(d.Args[0], strings.Join(parts, "")); err == nil {
+			formats[d.Args[0]] = format
+		}
+	}
+
+	return formats
+}
+
+// AccessFormat returns the format nginx uses to write logPath: the format
+// named by the matching access_log directive, or combined if it names none.
+// Relative access_log paths are taken relative to prefix, like nginx does.
+func AccessFormat(directives []*nginxconf.Directive, logPath string, prefix string) *Format {
+	formats := Formats(directives)
+
+	for _, d := range nginxconf.Find(directives, "access_log") {
+		path := d.Arg(0)
+		if path != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(prefix, path)
+		}
+		if path != logPath {
+			continue
+		}
+		name := d.Arg(1)
+		if name == "" || strings.Contains(name, "=") {
+			break
+		}
+		if format, ok := formats[name]; ok {
+			return format
+		}
+	}
+
+	return formats["combined"]
+}
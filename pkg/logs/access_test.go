@@ -0,0 +1,161 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompileFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		fields  []string
+	}{
+		{"combined", Combined, []string{"remote_addr", "remote_user", "time_local", "request", "status", "body_bytes_sent", "http_referer", "http_user_agent"}},
+		{"braced variables", `${remote_addr}:${status}`, []string{"remote_addr", "status"}},
+		{"no variables", "static text", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := CompileFormat(tt.name, tt.pattern)
+			if err != nil {
+				t.Fatalf("CompileFormat(%q): %v", tt.pattern, err)
+			}
+			if !reflect.DeepEqual(f.Fields(), tt.fields) {
+				t.Errorf("Fields() = %v, want %v", f.Fields(), tt.fields)
+			}
+		})
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	combined, err := CompileFormat("combined", Combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timed, err := CompileFormat("timed", `$remote_addr [$time_iso8601] $host "$request" $status $bytes_sent $request_time "$upstream_response_time"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := CompileFormat("json", `{"ip":"$remote_addr","ua":"$http_user_agent","status":$status}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		format *Format
+		line   string
+		ok     bool
+		want   Record // only the typed fields are compared
+	}{
+		{
+			name:   "combined",
+			format: combined,
+			line:   `203.0.113.9 - alice [17/Oct/2026:10:00:01 +0000] "GET /api/items?page=2 HTTP/1.1" 200 512 "https://example.com/" "curl/8.0"`,
+			ok:     true,
+			want: Record{
+				RemoteAddr: "203.0.113.9",
+				RemoteUser: "alice",
+				Time:       time.Date(2026, 10, 17, 10, 0, 1, 0, time.UTC),
+				Method:     "GET",
+				URI:        "/api/items?page=2",
+				Path:       "/api/items",
+				Protocol:   "HTTP/1.1",
+				Status:     200,
+				BytesSent:  512,
+				Referer:    "https://example.com/",
+				UserAgent:  "curl/8.0",
+			},
+		},
+		{
+			name:   "dashes are empty values",
+			format: combined,
+			line:   `203.0.113.9 - - [17/Oct/2026:10:00:01 +0000] "GET / HTTP/1.1" 404 0 "-" "-"`,
+			ok:     true,
+			want: Record{
+				RemoteAddr: "203.0.113.9",
+				Time:       time.Date(2026, 10, 17, 10, 0, 1, 0, time.UTC),
+				Method:     "GET",
+				URI:        "/",
+				Path:       "/",
+				Protocol:   "HTTP/1.1",
+				Status:     404,
+			},
+		},
+		{
+			name:   "escaped quote in a quoted value",
+			format: combined,
+			line:   `203.0.113.9 - - [17/Oct/2026:10:00:01 +0000] "GET / HTTP/1.1" 200 1 "-" "say \x22hi\x22"`,
+			ok:     true,
+			want: Record{
+				RemoteAddr: "203.0.113.9",
+				Time:       time.Date(2026, 10, 17, 10, 0, 1, 0, time.UTC),
+				Method:     "GET",
+				URI:        "/",
+				Path:       "/",
+				Protocol:   "HTTP/1.1",
+				Status:     200,
+				BytesSent:  1,
+				UserAgent:  `say "hi"`,
+			},
+		},
+		{
+			name:   "times and upstream retries",
+			format: timed,
+			line:   `10.0.0.1 [2026-10-17T10:00:01+00:00] example.com "POST /login HTTP/2.0" 502 157 0.250 "0.100, 0.150"`,
+			ok:     true,
+			want: Record{
+				RemoteAddr:              "10.0.0.1",
+				Time:                    time.Date(2026, 10, 17, 10, 0, 1, 0, time.UTC),
+				Method:                  "POST",
+				URI:                     "/login",
+				Path:                    "/login",
+				Protocol:                "HTTP/2.0",
+				Status:                  502,
+				BytesSent:               157,
+				Host:                    "example.com",
+				RequestTime:             250 * time.Millisecond,
+				HasRequestTime:          true,
+				UpstreamResponseTime:    250 * time.Millisecond,
+				HasUpstreamResponseTime: true,
+			},
+		},
+		{
+			name:   "json escapes",
+			format: json,
+			line:   `{"ip":"10.0.0.2","ua":"a \"quoted\" agent","status":301}`,
+			ok:     true,
+			want:   Record{RemoteAddr: "10.0.0.2", UserAgent: `a "quoted" agent`, Status: 301},
+		},
+		{
+			name:   "line of another format",
+			format: combined,
+			line:   `10.0.0.1 [2026-10-17T10:00:01+00:00] example.com "GET / HTTP/1.1" 200`,
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, ok := tt.format.Parse(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			got := *record
+			got.Raw, got.Fields = "", nil
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time, tt.want.Time = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
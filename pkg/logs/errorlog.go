@@ -0,0 +1,145 @@
+package logs
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Levels are the error log severities, least severe first
+var Levels = []string{"debug", "info", "notice", "warn", "error", "crit", "alert", "emerg"}
+
+// LevelRank returns the position of a level in Levels, or -1 if unknown
+func LevelRank(level string) int {
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// ErrorEntry is an error log line split into its parts:
+//
+//	2024/01/02 15:04:05 [error] 1234#5678: *90 connect() failed ..., client: 1.2.3.4, server: example.com, request: "GET / HTTP/1.1", upstream: "http://127.0.0.1:3000/", host: "example.com"
+type ErrorEntry struct {
+	Raw        string
+	Time       time.Time
+	Level      string
+	PID        int
+	TID        int
+	Connection int64 // 0 if the message isn't tied to a connection
+	Message    string
+	Client     string
+	Server     string
+	Request    string
+	Upstream   string
+	Host       string
+}
+
+var (
+	errorLinePattern = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(\w+)\] (\d+)#(\d+): (?:\*(\d+) )?(.*)$`)
+	// contextPattern matches the ", key: value" pairs nginx appends to messages
+	contextPattern = regexp.MustCompile(`, (client|server|request|upstream|host|referrer): ("(?:[^"\\]|\\.)*"|[^,]*)`)
+)
+
+// ParseErrorLine parses an error log line. ok is false for lines that don't
+// start with a timestamp and level, e.g. the continuation of a multi-line message.
+func ParseErrorLine(line string) (entry *ErrorEntry, ok bool) {
+	m := errorLinePattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+
+	e := &ErrorEntry{Raw: line, Level: m[2]}
+	e.Time, _ = time.ParseInLocation("2006/01/02 15:04:05", m[1], time.Local)
+	e.PID, _ = strconv.Atoi(m[3])
+	e.TID, _ = strconv.Atoi(m[4])
+	if m[5] != "" {
+		e.Connection, _ = strconv.ParseInt(m[5], 10, 64)
+	}
+
+	// The context starts at ", client: " (or the first known key) and runs to the end
+	message := m[6]
+	if loc := contextPattern.FindStringIndex(message); loc != nil {
+		for _, pair := range contextPattern.FindAllStringSubmatch(message[loc[0]:], -1) {
+			value := strings.Trim(pair[2], `"`)
+			switch pair[1] {
+			case "client":
+				e.Client = value
+			case "server":
+				e.Server = value
+			case "request":
+				e.Request = value
+			case "upstream":
+				e.Upstream = value
+			case "host":
+				e.Host = value
+			}
+		}
+		message = message[:loc[0]]
+	}
+	e.Message = message
+	return e, true
+}
+
+// ErrorGroup is a message repeated in the error log
+type ErrorGroup struct {
+	Level    string
+	Message  string
+	Server   string
+	Upstream string // scheme and address of the upstream, without the URI
+	Count    int
+	First    time.Time
+	Last     time.Time
+	Latest   *ErrorEntry // most recent occurrence
+}
+
+// GroupErrors groups entries with the same level, message, server and
+// upstream address, in the order of their latest occurrence. Entries below
+// minLevel are left out; an empty minLevel keeps everything.
+func GroupErrors(entries []*ErrorEntry, minLevel string) []*ErrorGroup {
+	minRank := LevelRank(minLevel)
+
+	var groups []*ErrorGroup
+	index := map[string]*ErrorGroup{}
+	for _, e := range entries {
+		if minLevel != "" && LevelRank(e.Level) < minRank {
+			continue
+		}
+
+		upstream := UpstreamOrigin(e.Upstream)
+		key := strings.Join([]string{e.Level, e.Message, e.Server, upstream}, "\x00")
+		group, ok := index[key]
+		if !ok {
+			group = &ErrorGroup{Level: e.Level, Message: e.Message, Server: e.Server, Upstream: upstream, First: e.Time}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.Count++
+		group.Last = e.Time
+		group.Latest = e
+	}
+
+	// Newest last, like the log itself
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Last.Before(groups[j].Last) })
+	return groups
+}
+
+// UpstreamOrigin strips the URI from an upstream URL as nginx logs it:
+// "http://127.0.0.1:3000/api" -> "http://127.0.0.1:3000",
+// "http://unix:/run/app.sock:/api" -> "http://unix:/run/app.sock"
+func UpstreamOrigin(upstream string) string {
+	scheme, rest, found := strings.Cut(upstream, "://")
+	if !found {
+		return upstream
+	}
+	if socket, ok := strings.CutPrefix(rest, "unix:"); ok {
+		socket, _, _ = strings.Cut(socket, ":")
+		return scheme + "://unix:" + socket
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	return scheme + "://" + host
+}
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrorLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		ok   bool
+		want ErrorEntry // Raw is not compared
+	}{
+		{
+			name: "upstream error with context",
+			line: `2026/10/16 09:30:00 [error] 1234#5678: *90 connect() failed (111: Connection refused) while connecting to upstream, client: 203.0.113.9, server: example.com, request: "GET /api HTTP/1.1", upstream: "http://127.0.0.1:3000/api", host: "example.com"`,
+			ok:   true,
+			want: ErrorEntry{
+				Time:       time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local),
+				Level:      "error",
+				PID:        1234,
+				TID:        5678,
+				Connection: 90,
+				Message:    "connect() failed (111: Connection refused) while connecting to upstream",
+				Client:     "203.0.113.9",
+				Server:     "example.com",
+				Request:    "GET /api HTTP/1.1",
+				Upstream:   "http://127.0.0.1:3000/api",
+				Host:       "example.com",
+			},
+		},
+		{
+			name: "message without a connection",
+			line: `2026/10/16 09:00:00 [notice] 1#1: signal process started`,
+			ok:   true,
+			want: ErrorEntry{
+				Time:    time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local),
+				Level:   "notice",
+				PID:     1,
+				TID:     1,
+				Message: "signal process started",
+			},
+		},
+		{
+			name: "commas in the message are kept",
+			line: `2026/10/16 09:00:00 [warn] 7#7: *3 an upstream response is buffered to a temporary file, client: 10.0.0.1, server: _`,
+			ok:   true,
+			want: ErrorEntry{
+				Time:       time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local),
+				Level:      "warn",
+				PID:        7,
+				TID:        7,
+				Connection: 3,
+				Message:    "an upstream response is buffered to a temporary file",
+				Client:     "10.0.0.1",
+				Server:     "_",
+			},
+		},
+		{
+			name: "continuation line",
+			line: `    in /etc/nginx/nginx.conf:12`,
+			ok:   false,
+		},
+		{
+			name: "access log line",
+			line: `203.0.113.9 - - [17/Oct/2026:10:00:01 +0000] "GET / HTTP/1.1" 200 1 "-" "-"`,
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := ParseErrorLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ParseErrorLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			got := *entry
+			got.Raw = ""
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time, tt.want.Time = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("ParseErrorLine(%q) =\n%+v\nwant\n%+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
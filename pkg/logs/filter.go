@@ -0,0 +1,302 @@
+package logs
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects access log records with expressions like
+//
+//	status>=500 path~^/api ip=10.0.0.0/8 since=15m
+//
+// Every term must match. A term without an operator matches the raw line
+// as text.
+type Filter struct {
+	Expr  string
+	terms []func(r *Record) bool
+}
+
+// filterOperators in the order they are looked for, so ">=" wins over ">"
+var filterOperators = []string{"!~", "!=", ">=", "<=", "~", "=", ">", "<"}
+
+// FilterFields lists the fields a filter term can use
+var FilterFields = []string{"status", "method", "path", "uri", "ip", "host", "ua", "referer", "bytes", "rt", "urt", "since", "until"}
+
+// ParseFilter compiles a filter expression. since and until take a
+// duration back from now (15m, 2h, 1d) or a time (2006-01-02T15:04, 15:04).
+func ParseFilter(expr string, now time.Time) (*Filter, error) {
+	f := &Filter{Expr: expr}
+	for _, term := range strings.Fields(expr) {
+		match, err := parseTerm(term, now)
+		if err != nil {
+			return nil, err
+		}
+		f.terms = append(f.terms, match)
+	}
+	return f, nil
+}
+
+// Match reports whether a record matches every term of the filter
+func (f *Filter) Match(r *Record) bool {
+	for _, term := range f.terms {
+		if !term(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the filter has no terms and matches everything
+func (f *Filter) Empty() bool {
+	return len(f.terms) == 0
+}
+
+func parseTerm(term string, now time.Time) (func(r *Record) bool, error) {
+	// The field is the leading word, the operator follows it directly
+	field, op, value := "", "", ""
+	end := strings.IndexFunc(term, func(c rune) bool { return (c < 'a' || c > 'z') && c != '_' })
+	if end > 0 {
+		for _, candidate := range filterOperators {
+			if strings.HasPrefix(term[end:], candidate) {
+				field, op, value = term[:end], candidate, term[end+len(candidate):]
+				break
+			}
+		}
+	}
+	if op == "" {
+		// Plain text
+		text := strings.ToLower(term)
+		return func(r *Record) bool { return strings.Contains(strings.ToLower(r.Raw), text) }, nil
+	}
+
+	switch field {
+	case "status":
+		return statusTerm(op, value)
+	case "method":
+		return textTerm(term, op, value, func(r *Record) string { return r.Method })
+	case "path":
+		return textTerm(term, op, value, func(r *Record) string { return r.Path })
+	case "uri":
+		return textTerm(term, op, value, func(r *Record) string { return r.URI })
+	case "host":
+		return textTerm(term, op, value, func(r *Record) string { return r.Host })
+	case "ua":
+		return textTerm(term, op, value, func(r *Record) string { return r.UserAgent })
+	case "referer":
+		return textTerm(term, op, value, func(r *Record) string { return r.Referer })
+	case "ip":
+		return ipTerm(term, op, value)
+	case "bytes":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", term, value)
+		}
+		return numberTerm(term, op, func(r *Record) (float64, bool) { return float64(r.BytesSent), true }, float64(n))
+	case "rt", "urt":
+		seconds, err := strconv.ParseFloat(strings.TrimSuffix(value, "s"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number of seconds", term, value)
+		}
+		get := func(r *Record) (float64, bool) { return r.RequestTime.Seconds(), r.HasRequestTime }
+		if field == "urt" {
+			get = func(r *Record) (float64, bool) { return r.UpstreamResponseTime.Seconds(), r.HasUpstreamResponseTime }
+		}
+		return numberTerm(term, op, get, seconds)
+	case "since", "until":
+		if op != "=" {
+			return nil, fmt.Errorf("%s: use %s=<time>", term, field)
+		}
+		at, err := parseFilterTime(value, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", term, err)
+		}
+		if field == "since" {
+			return func(r *Record) bool { return !r.Time.IsZero() && !r.Time.Before(at) }, nil
+		}
+		return func(r *Record) bool { return !r.Time.IsZero() && !r.Time.After(at) }, nil
+	}
+
+	return nil, fmt.Errorf("%s: unknown field %q (use %s)", term, field, strings.Join(FilterFields, ", "))
+}
+
+// statusTerm compares status codes; "5xx" matches a whole class with = and !=
+func statusTerm(op string, value string) (func(r *Record) bool, error) {
+	if len(value) == 3 && strings.HasSuffix(strings.ToLower(value), "xx") && value[0] >= '1' && value[0] <= '5' {
+		class := int(value[0] - '0')
+		switch op {
+		case "=":
+			return func(r *Record) bool { return r.Status/100 == class }, nil
+		case "!=":
+			return func(r *Record) bool { return r.Status/100 != class }, nil
+		}
+		return nil, fmt.Errorf("status%s%s: a status class only works with = and !=", op, value)
+	}
+
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("status%s%s: %q is not a status code", op, value, value)
+	}
+	return numberTerm("status"+op+value, op, func(r *Record) (float64, bool) { return float64(r.Status), r.Status != 0 }, float64(code))
+}
+
+// textTerm matches a text field exactly (=, !=) or with a regexp (~, !~)
+func textTerm(term string, op string, value string, get func(r *Record) string) (func(r *Record) bool, error) {
+	switch op {
+	case "=":
+		return func(r *Record) bool { return get(r) == value }, nil
+	case "!=":
+		return func(r *Record) bool { return get(r) != value }, nil
+	case "~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", term, err)
+		}
+		if op == "~" {
+			return func(r *Record) bool { return re.MatchString(get(r)) }, nil
+		}
+		return func(r *Record) bool { return !re.MatchString(get(r)) }, nil
+	}
+	return nil, fmt.Errorf("%s: use =, !=, ~ or !~", term)
+}
+
+// numberTerm compares a numeric field; records without the field never match
+func numberTerm(term string, op string, get func(r *Record) (float64, bool), value float64) (func(r *Record) bool, error) {
+	var compare func(a float64) bool
+	switch op {
+	case "=":
+		compare = func(a float64) bool { return a == value }
+	case "!=":
+		compare = func(a float64) bool { return a != value }
+	case ">":
+		compare = func(a float64) bool { return a > value }
+	case ">=":
+		compare = func(a float64) bool { return a >= value }
+	case "<":
+		compare = func(a float64) bool { return a < value }
+	case "<=":
+		compare = func(a float64) bool { return a <= value }
+	default:
+		return nil, fmt.Errorf("%s: use =, !=, >, >=, < or <=", term)
+	}
+	return func(r *Record) bool {
+		a, ok := get(r)
+		return ok && compare(a)
+	}, nil
+}
+
+// ipTerm matches the client address against an address or a CIDR range
+func ipTerm(term string, op string, value string) (func(r *Record) bool, error) {
+	if op != "=" && op != "!=" {
+		return nil, fmt.Errorf("%s: use ip=<address or range> or ip!=", term)
+	}
+
+	var contains func(ip net.IP) bool
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a CIDR range", term, value)
+		}
+		contains = network.Contains
+	} else {
+		want := net.ParseIP(value)
+		if want == nil {
+			return nil, fmt.Errorf("%s: %q is not an IP address", term, value)
+		}
+		contains = want.Equal
+	}
+
+	return func(r *Record) bool {
+		ip := net.ParseIP(r.RemoteAddr)
+		in := ip != nil && contains(ip)
+		return in == (op == "=")
+	}, nil
+}
+
+// parseFilterTime reads "15m", "2h", "1d" as that long before now, or a local
+// time as "2006-01-02T15:04", "2006-01-02 15:04" or "15:04" (today)
+func parseFilterTime(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration (15m, 2h, 1d) or a time (2006-01-02T15:04, 15:04)", value)
+}
+
+// Span is a time span of a log. A zero Since or Until leaves that end open.
+type Span struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseSpan reads a time span written like the since and until terms of a
+// filter, e.g. "since=2026-01-02T09:00 until=2026-01-02T11:30"
+func ParseSpan(expr string, now time.Time) (Span, error) {
+	var span Span
+	for _, term := range strings.Fields(expr) {
+		field, value, ok := strings.Cut(term, "=")
+		if !ok || (field != "since" && field != "until") {
+			return Span{}, fmt.Errorf("%s: use since=<time> and until=<time>", term)
+		}
+		at, err := parseFilterTime(value, now)
+		if err != nil {
+			return Span{}, fmt.Errorf("%s: %v", term, err)
+		}
+		if field == "since" {
+			span.Since = at
+		} else {
+			span.Until = at
+		}
+	}
+
+	if span.Empty() {
+		return Span{}, fmt.Errorf("type since=<time>, until=<time> or both")
+	}
+	if !span.Since.IsZero() && !span.Until.IsZero() && span.Until.Before(span.Since) {
+		return Span{}, fmt.Errorf("until is before since")
+	}
+	return span, nil
+}
+
+// Empty reports whether the span has neither end and covers the whole log
+func (s Span) Empty() bool {
+	return s.Since.IsZero() && s.Until.IsZero()
+}
+
+// Contains reports whether t falls in the span. A zero time never does.
+func (s Span) Contains(t time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+	return (s.Since.IsZero() || !t.Before(s.Since)) && (s.Until.IsZero() || !t.After(s.Until))
+}
+
+// String describes the span, e.g. "since 2026-01-02 09:00:00 until 2026-01-02 11:30:00"
+func (s Span) String() string {
+	var parts []string
+	if !s.Since.IsZero() {
+		parts = append(parts, "since "+s.Since.Format("2006-01-02 15:04:05"))
+	}
+	if !s.Until.IsZero() {
+		parts = append(parts, "until "+s.Until.Format("2006-01-02 15:04:05"))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	record := &Record{
+		Raw:            `203.0.113.9 - - [17/Oct/2026:11:50:00 +0000] "GET /api/items?page=2 HTTP/1.1" 503 512 "-" "curl/8.0"`,
+		RemoteAddr:     "203.0.113.9",
+		Time:           now.Add(-10 * time.Minute),
+		Method:         "GET",
+		URI:            "/api/items?page=2",
+		Path:           "/api/items",
+		Status:         503,
+		BytesSent:      512,
+		UserAgent:      "curl/8.0",
+		Host:           "example.com",
+		RequestTime:    1500 * time.Millisecond,
+		HasRequestTime: true,
+	}
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{"", true},
+		{"status=503", true},
+		{"status>=500", true},
+		{"status<500", false},
+		{"status=5xx", true},
+		{"status!=5xx", false},
+		{"method=GET path~^/api", true},
+		{"method=GET path~^/admin", false},
+		{"path!~^/static", true},
+		{"uri=/api/items?page=2", true},
+		{"host=example.com", true},
+		{"ua~curl", true},
+		{"ip=203.0.113.0/24", true},
+		{"ip!=203.0.113.9", false},
+		{"bytes>1000", false},
+		{"rt>1", true},
+		{"rt>1s urt>0", false}, // no upstream time logged
+		{"since=15m", true},
+		{"since=5m", false},
+		{"until=11:55", true},
+		{"since=2026-10-17T11:00 until=2026-10-17T11:45", false},
+		{"CURL", true},
+		{"wget", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr, now)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+			}
+			if f.Empty() != (tt.expr == "") {
+				t.Errorf("Empty() = %v", f.Empty())
+			}
+			if got := f.Match(record); got != tt.match {
+				t.Errorf("Match = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		"colour=red",
+		"status=abc",
+		"status>5xx",
+		"method>GET",
+		"path~[",
+		"ip=not-an-ip",
+		"ip=10.0.0.0/99",
+		"ip>10.0.0.1",
+		"bytes=lots",
+		"rt=fast",
+		"since>15m",
+		"since=yesterday",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseFilter(expr, time.Now()); err == nil {
+				t.Errorf("ParseFilter(%q) succeeded, want an error", expr)
+			}
+		})
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	at := func(hour, min int) time.Time { return time.Date(2026, 10, 17, hour, min, 0, 0, time.Local) }
+
+	tests := []struct {
+		expr string
+		want Span
+		err  bool
+	}{
+		{expr: "since=2026-10-17T09:00 until=2026-10-17T11:30", want: Span{Since: at(9, 0), Until: at(11, 30)}},
+		{expr: "since=1h", want: Span{Since: at(11, 0)}},
+		{expr: "until=10:15", want: Span{Until: at(10, 15)}},
+		{expr: "", err: true},
+		{expr: "status=500", err: true},
+		{expr: "since=soon", err: true},
+		{expr: "since=11:00 until=10:00", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			span, err := ParseSpan(tt.expr, now)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseSpan(%q) error = %v, want error %v", tt.expr, err, tt.err)
+			}
+			if !span.Since.Equal(tt.want.Since) || !span.Until.Equal(tt.want.Until) {
+				t.Errorf("ParseSpan(%q) = %v, want %v", tt.expr, span, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpanContains(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2026, 10, 17, hour, 0, 0, 0, time.UTC) }
+	span := Span{Since: at(9), Until: at(11)}
+
+	tests := []struct {
+		span Span
+		t    time.Time
+		want bool
+	}{
+		{span, at(9), true},
+		{span, at(11), true},
+		{span, at(8), false},
+		{span, at(12), false},
+		{span, time.Time{}, false},
+		{Span{Since: at(9)}, at(23), true},
+		{Span{Until: at(11)}, at(0), true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.span.Contains(tt.t); got != tt.want {
+			t.Errorf("%v Contains(%v) = %v, want %v", tt.span, tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package logs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStopped is returned by Next once the follower has been stopped
+var ErrStopped = errors.New("follow stopped")
+
+const (
+	// pollInterval is how often the file is checked for new data
+	pollInterval = 250 * time.Millisecond
+	// readChunk bounds how much is read per poll, so a burst of writes
+	// doesn't have to fit in memory at once
+	readChunk = 64 * 1024
+	// backlogBytes is how far back Follow looks for the initial lines, or
+	// backlogLineBytes for each line when more lines are asked for
+	backlogBytes     = 256 * 1024
+	backlogLineBytes = 4 * 1024
+)
+
+// Follower reads lines appended to a file, like tail -F. It reopens the
+// path when the file is rotated and starts over when it is truncated.
+type Follower struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial string // last line, until its newline is written
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Follow opens path and returns a follower positioned at the end of the
+// file, together with up to backlog of the file's last lines
+func Follow(path string, backlog int) (*Follower, []string, error) {
+	f := &Follower{path: path, stop: make(chan struct{})}
+	if err := f.open(); err != nil {
+		return nil, nil, err
+	}
+
+	lines, err := f.lastLines(backlog)
+	if err != nil {
+		f.file.Close()
+		return nil, nil, err
+	}
+	return f, lines, nil
+}
+
+// Path returns the followed path
+func (f *Follower) Path() string {
+	return f.path
+}
+
+// Next blocks until new complete lines are available and returns them.
+// It returns ErrStopped, and closes the file, once Stop has been called.
+func (f *Follower) Next() ([]string, error) {
+	for {
+		select {
+		case <-f.stop:
+			f.close()
+			return nil, ErrStopped
+		default:
+		}
+
+		lines, err := f.poll()
+		if err != nil || len(lines) > 0 {
+			return lines, err
+		}
+
+		select {
+		case <-f.stop:
+			f.close()
+			return nil, ErrStopped
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Stop ends the follow and wakes up a pending Next. It is safe to call more than once.
+func (f *Follower) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+func (f *Follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *Follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.info, f.offset, f.partial = file, info, 0, ""
+	return nil
+}
+
+// lastLines reads the last n lines and leaves the offset at the end of the file
+func (f *Follower) lastLines(n int) ([]string, error) {
+	lines, partial, _, err := readTail(f.file, f.info.Size(), max(backlogBytes, int64(n)*backlogLineBytes))
+	if err != nil {
+		return nil, err
+	}
+	f.offset = f.info.Size()
+	f.partial = partial
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// poll returns the complete lines written since the last call
+func (f *Follower) poll() ([]string, error) {
+	if f.file == nil {
+		// The file was missing after a rotation; wait for it to come back
+		if err := f.open(); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	lines, err := f.read()
+	if err != nil || len(lines) > 0 {
+		return lines, err
+	}
+
+	// Nothing new: check whether the path now points at another file
+	// (rotation) or the file got shorter (truncation)
+	current, err := os.Stat(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			f.close()
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !os.SameFile(current, f.info) {
+		f.close()
+		if err := f.open(); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return f.read()
+	}
+	if current.Size() < f.offset {
+		f.offset, f.partial = 0, ""
+		return f.read()
+	}
+	return nil, nil
+}
+
+// read reads up to readChunk bytes from the offset
+func (f *Follower) read() ([]string, error) {
+	buf := make([]byte, readChunk)
+	n, err := f.file.ReadAt(buf, f.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	f.offset += int64(n)
+
+	lines, partial := splitComplete(f.partial + string(buf[:n]))
+	// A line without a newline can't grow without bound
+	if len(partial) > readChunk {
+		lines = append(lines, partial)
+		partial = ""
+	}
+	f.partial = partial
+	return lines, nil
+}
+
+// splitComplete splits text into complete lines and the unterminated rest
+func splitComplete(text string) ([]string, string) {
+	i := strings.LastIndexByte(text, '\n')
+	if i < 0 {
+		return nil, text
+	}
+	return strings.Split(text[:i], "\n"), text[i+1:]
+}
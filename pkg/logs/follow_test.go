@@ -0,0 +1,158 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func appendFile(t *testing.T, path string, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		change  func(t *testing.T, path string)
+		want    []string // lines returned by the following polls
+	}{
+		{
+			name:    "appended lines",
+			initial: "a\nb\n",
+			change:  func(t *testing.T, path string) { appendFile(t, path, "c\nd\n") },
+			want:    []string{"c", "d"},
+		},
+		{
+			name:    "line completed by a later write",
+			initial: "a\nhal",
+			change:  func(t *testing.T, path string) { appendFile(t, path, "f\nnext\n") },
+			want:    []string{"half", "next"},
+		},
+		{
+			name:    "unterminated line is held back",
+			initial: "a\n",
+			change:  func(t *testing.T, path string) { appendFile(t, path, "b\nno newline") },
+			want:    []string{"b"},
+		},
+		{
+			name:    "truncated to a shorter file",
+			initial: "first line\nsecond line\n",
+			change: func(t *testing.T, path string) {
+				if err := os.Truncate(path, 0); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, path, "x\n")
+			},
+			want: []string{"x"},
+		},
+		{
+			name:    "rename rotation",
+			initial: "a\n",
+			change: func(t *testing.T, path string) {
+				appendFile(t, path, "before rotation\n")
+				if err := os.Rename(path, path+".1"); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, path, "after rotation\n")
+			},
+			want: []string{"before rotation", "after rotation"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "access.log")
+			appendFile(t, path, tt.initial)
+
+			f, _, err := Follow(path, 0)
+			if err != nil {
+				t.Fatalf("Follow: %v", err)
+			}
+			defer f.close()
+
+			tt.change(t, path)
+			var got []string
+			for i := 0; i < 3; i++ {
+				lines, err := f.poll()
+				if err != nil {
+					t.Fatalf("poll: %v", err)
+				}
+				got = append(got, lines...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowRecreated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	appendFile(t, path, "old\n")
+	f, _, err := Follow(path, 0)
+	if err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+	defer f.close()
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if lines, err := f.poll(); err != nil || len(lines) != 0 {
+		t.Fatalf("poll after remove = %q, %v, want nothing", lines, err)
+	}
+	appendFile(t, path, "new\n")
+	if lines, err := f.poll(); err != nil || !reflect.DeepEqual(lines, []string{"new"}) {
+		t.Errorf("poll after recreate = %q, %v, want [new]", lines, err)
+	}
+}
+
+func TestFollowBacklog(t *testing.T) {
+	tests := []struct {
+		content string
+		backlog int
+		want    []string
+	}{
+		{"a\nb\nc\n", 2, []string{"b", "c"}},
+		{"a\nb\nc\n", 10, []string{"a", "b", "c"}},
+		{"a\nb\nc\n", 0, nil},
+		{"a\nb\npartial", 5, []string{"a", "b"}},
+		{"", 5, nil},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "access.log")
+		appendFile(t, path, tt.content)
+
+		f, lines, err := Follow(path, tt.backlog)
+		if err != nil {
+			t.Fatalf("Follow: %v", err)
+		}
+		f.Stop()
+		if _, err := f.Next(); err != ErrStopped {
+			t.Errorf("Next after Stop = %v, want ErrStopped", err)
+		}
+		if len(lines) == 0 {
+			lines = nil
+		}
+		if !reflect.DeepEqual(lines, tt.want) {
+			t.Errorf("Follow(%q, %d) backlog = %q, want %q", tt.content, tt.backlog, lines, tt.want)
+		}
+	}
+}
+
+func TestFollowMissing(t *testing.T) {
+	if _, _, err := Follow(filepath.Join(t.TempDir(), "missing.log"), 10); !os.IsNotExist(err) {
+		t.Errorf("Follow of a missing file = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,79 @@
+package logs
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadLast returns the complete lines in the last maxBytes of a file.
+// truncated is true when the file is longer, so older lines were left out.
+// Gzipped logs are decompressed.
+func ReadLast(path string, maxBytes int64) (lines []string, truncated bool, err error) {
+	if strings.HasSuffix(path, ".gz") {
+		return readLastCompressed(path, maxBytes)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	lines, _, truncated, err = readTail(file, info.Size(), maxBytes)
+	return lines, truncated, err
+}
+
+// readLastCompressed reads a gzipped log from the start, as it can't be read
+// backwards, keeping the lines of its last maxBytes
+func readLastCompressed(path string, maxBytes int64) (lines []string, truncated bool, err error) {
+	reader, err := Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	var size int64
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lines = append(lines, line)
+		size += int64(len(line)) + 1
+		for size > maxBytes {
+			size -= int64(len(lines[0])) + 1
+			lines = lines[1:]
+			truncated = true
+		}
+	}
+	return lines, truncated, scanner.Err()
+}
+
+// readTail reads the end of a file of the given size, at most maxBytes.
+// It returns the complete lines and the unterminated last line.
+func readTail(file *os.File, size int64, maxBytes int64) (lines []string, partial string, truncated bool, err error) {
+	start := size - maxBytes
+	if start < 0 {
+		start = 0
+	}
+
+	buf := make([]byte, size-start)
+	if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return nil, "", false, err
+	}
+
+	text := string(buf)
+	if start > 0 {
+		// Drop the first line, it was cut off by the read window
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	lines, partial = splitComplete(text)
+	return lines, partial, start > 0, nil
+}
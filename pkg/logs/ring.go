@@ -0,0 +1,43 @@
+package logs
+
+// Ring keeps the last lines pushed into it, dropping the oldest once full
+type Ring struct {
+	lines []string
+	start int // index of the oldest line
+	size  int
+}
+
+// NewRing returns a ring holding at most capacity lines
+func NewRing(capacity int) *Ring {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Ring{lines: make([]string, capacity)}
+}
+
+// Push appends lines, overwriting the oldest when the ring is full
+func (r *Ring) Push(lines ...string) {
+	for _, line := range lines {
+		if r.size < len(r.lines) {
+			r.lines[(r.start+r.size)%len(r.lines)] = line
+			r.size++
+			continue
+		}
+		r.lines[r.start] = line
+		r.start = (r.start + 1) % len(r.lines)
+	}
+}
+
+// Lines returns the buffered lines, oldest first
+func (r *Ring) Lines() []string {
+	out := make([]string, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		out = append(out, r.lines[(r.start+i)%len(r.lines)])
+	}
+	return out
+}
+
+// Len returns the number of buffered lines
+func (r *Ring) Len() int {
+	return r.size
+}
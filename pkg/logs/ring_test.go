@@ -0,0 +1,42 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		pushes   [][]string
+		want     []string
+	}{
+		{"empty", 3, nil, nil},
+		{"below capacity", 3, [][]string{{"a", "b"}}, []string{"a", "b"}},
+		{"at capacity", 3, [][]string{{"a", "b", "c"}}, []string{"a", "b", "c"}},
+		{"one past capacity", 3, [][]string{{"a", "b", "c", "d"}}, []string{"b", "c", "d"}},
+		{"wrapped more than once", 3, [][]string{{"a", "b", "c", "d", "e", "f", "g"}}, []string{"e", "f", "g"}},
+		{"over several pushes", 3, [][]string{{"a", "b"}, {"c"}, {"d", "e"}}, []string{"c", "d", "e"}},
+		{"capacity below one holds one line", 0, [][]string{{"a", "b"}}, []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRing(tt.capacity)
+			for _, lines := range tt.pushes {
+				r.Push(lines...)
+			}
+			got := r.Lines()
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %q, want %q", got, tt.want)
+			}
+			if r.Len() != len(tt.want) {
+				t.Errorf("Len() = %d, want %d", r.Len(), len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package logs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogFile is a log or one of its rotated copies
+type LogFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time // last write, the end of the span the file covers
+	From    time.Time // ModTime of the next older file, zero for the oldest
+}
+
+// Compressed reports whether the file is gzipped
+func (f LogFile) Compressed() bool {
+	return strings.HasSuffix(f.Path, ".gz")
+}
+
+// rotatedSuffix matches what logrotate appends to a log name:
+// ".1", ".2.gz", or with dateext "-20240102" and "-20240102.gz"
+var rotatedSuffix = regexp.MustCompile(`^(\.\d+|-\d{8}(\d{2})?)(\.gz)?$`)
+
+// rotatedEnding matches the same suffix at the end of a file name
+var rotatedEnding = regexp.MustCompile(`(\.\d+|-\d{8}(\d{2})?)(\.gz)?$`)
+
+// LivePath returns the log a rotated copy was rotated from: access.log for
+// access.log.1 or access.log.2.gz. Other paths are returned as they are.
+func LivePath(path string) string {
+	dir, name := filepath.Split(path)
+	if loc := rotatedEnding.FindStringIndex(name); loc != nil && loc[0] > 0 {
+		return dir + name[:loc[0]]
+	}
+	return path
+}
+
+// Rotated returns a log and its rotated siblings, newest first.
+// The log itself is left out if it doesn't exist, e.g. right after a rotation.
+func Rotated(path string) ([]LogFile, error) {
+	dir, base := filepath.Dir(path), filepath.Base(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []LogFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		if suffix := name[len(base):]; suffix != "" && !rotatedSuffix.MatchString(suffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, LogFile{Path: filepath.Join(dir, name), Size: info.Size(), ModTime: info.ModTime()})
+	}
+
+	// The live log first, then by last write
+	sort.SliceStable(files, func(i, j int) bool {
+		if (files[i].Path == path) != (files[j].Path == path) {
+			return files[i].Path == path
+		}
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	for i := 0; i+1 < len(files); i++ {
+		files[i].From = files[i+1].ModTime
+	}
+	return files, nil
+}
+
+// Open opens a log for reading, decompressing gzipped files
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return gzipFile{Reader: reader, file: file}, nil
+}
+
+// gzipFile closes the decompressor and the file beneath it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// Tail returns the last n lines of a log, reading it from the start.
+// Gzipped logs can't be read backwards, so this is the way to tail them.
+func Tail(path string, n int) ([]string, error) {
+	reader, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	ring := NewRing(n)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		ring.Push(scanner.Text())
+	}
+	return ring.Lines(), scanner.Err()
+}
@@ -0,0 +1,106 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRotated(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	// name and age in hours; the live log is written last but listed first anyway
+	files := []struct {
+		name string
+		age  int
+	}{
+		{"access.log", 0},
+		{"access.log.1", 24},
+		{"access.log.2.gz", 48},
+		{"access.log-20261014", 72},
+		{"access.log-2026101400.gz", 96},
+		{"access.log.bak", 1},
+		{"access.log.1.old", 1},
+		{"error.log", 0},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte("line\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		at := now.Add(-time.Duration(f.age) * time.Hour)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Rotated(filepath.Join(dir, "access.log"))
+	if err != nil {
+		t.Fatalf("Rotated: %v", err)
+	}
+
+	var names []string
+	for _, f := range got {
+		names = append(names, filepath.Base(f.Path))
+	}
+	want := []string{"access.log", "access.log.1", "access.log.2.gz", "access.log-20261014", "access.log-2026101400.gz"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("Rotated = %v, want %v", names, want)
+	}
+
+	for i, f := range got {
+		var from time.Time
+		if i+1 < len(got) {
+			from = got[i+1].ModTime
+		}
+		if !f.From.Equal(from) {
+			t.Errorf("%s From = %v, want %v", names[i], f.From, from)
+		}
+		if f.Compressed() != (filepath.Ext(f.Path) == ".gz") {
+			t.Errorf("%s Compressed() = %v", names[i], f.Compressed())
+		}
+	}
+}
+
+func TestRotatedWithoutLiveLog(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "access.log.1"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Rotated(filepath.Join(dir, "access.log"))
+	if err != nil {
+		t.Fatalf("Rotated: %v", err)
+	}
+	if len(got) != 1 || filepath.Base(got[0].Path) != "access.log.1" {
+		t.Errorf("Rotated = %+v, want only access.log.1", got)
+	}
+
+	if _, err := Rotated(filepath.Join(dir, "missing", "access.log")); err == nil {
+		t.Error("Rotated in a missing directory succeeded, want an error")
+	}
+}
+
+func TestLivePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/var/log/nginx/access.log", "/var/log/nginx/access.log"},
+		{"/var/log/nginx/access.log.1", "/var/log/nginx/access.log"},
+		{"/var/log/nginx/access.log.2.gz", "/var/log/nginx/access.log"},
+		{"/var/log/nginx/access.log-20261014", "/var/log/nginx/access.log"},
+		{"/var/log/nginx/access.log-2026101400.gz", "/var/log/nginx/access.log"},
+		{"/var/log/nginx/.1", "/var/log/nginx/.1"},
+		{"/var/log/nginx/site.gz", "/var/log/nginx/site.gz"},
+	}
+
+	for _, tt := range tests {
+		if got := LivePath(tt.path); got != tt.want {
+			t.Errorf("LivePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
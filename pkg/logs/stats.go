@@ -0,0 +1,141 @@
+package logs
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Count is a value and how many requests had it
+type Count struct {
+	Value string
+	Count int
+}
+
+// Percentiles of a latency distribution
+type Percentiles struct {
+	Samples int
+	P50     time.Duration
+	P90     time.Duration
+	P95     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+}
+
+// Stats aggregates the access log records of a time window
+type Stats struct {
+	Window   time.Duration
+	From, To time.Time
+	Total    int
+	Span     time.Duration // part of the window the records cover, used for the rate
+	PerSec   float64
+	Classes  [6]int // requests per status class: Classes[2] is 2xx ... Classes[5] is 5xx
+	Buckets  []int  // requests per equal slice of the window, oldest first, for sparklines
+	Paths    []Count
+	IPs      []Count
+	Agents   []Count
+	Latency  Percentiles // from $request_time
+	Upstream Percentiles // from $upstream_response_time
+}
+
+// Summarize aggregates the records logged in the window ending at now.
+// partial tells that older lines of the log were not read, so the rate
+// is computed over the time the records cover instead of the whole window.
+func Summarize(records []*Record, window time.Duration, now time.Time, buckets int, top int, partial bool) Stats {
+	stats := Stats{Window: window, From: now.Add(-window), To: now, Buckets: make([]int, buckets)}
+
+	paths := map[string]int{}
+	ips := map[string]int{}
+	agents := map[string]int{}
+	var latencies, upstream []time.Duration
+	var oldest time.Time
+
+	for _, r := range records {
+		if !r.Time.IsZero() && (oldest.IsZero() || r.Time.Before(oldest)) {
+			oldest = r.Time
+		}
+		if r.Time.IsZero() || r.Time.Before(stats.From) || r.Time.After(now) {
+			continue
+		}
+		stats.Total++
+
+		if class := r.Status / 100; class >= 1 && class <= 5 {
+			stats.Classes[class]++
+		}
+		if buckets > 0 {
+			i := int(int64(r.Time.Sub(stats.From)) * int64(buckets) / int64(window))
+			if i >= buckets {
+				i = buckets - 1
+			}
+			stats.Buckets[i]++
+		}
+
+		if r.Path != "" {
+			paths[r.Path]++
+		}
+		if r.RemoteAddr != "" {
+			ips[r.RemoteAddr]++
+		}
+		if r.UserAgent != "" {
+			agents[r.UserAgent]++
+		}
+		if r.HasRequestTime {
+			latencies = append(latencies, r.RequestTime)
+		}
+		if r.HasUpstreamResponseTime {
+			upstream = append(upstream, r.UpstreamResponseTime)
+		}
+	}
+
+	// Without the older lines the window may be only partly covered
+	stats.Span = window
+	if partial && oldest.After(stats.From) {
+		stats.Span = now.Sub(oldest)
+	}
+	if stats.Span > 0 {
+		stats.PerSec = float64(stats.Total) / stats.Span.Seconds()
+	}
+
+	stats.Paths = topCounts(paths, top)
+	stats.IPs = topCounts(ips, top)
+	stats.Agents = topCounts(agents, top)
+	stats.Latency = percentiles(latencies)
+	stats.Upstream = percentiles(upstream)
+	return stats
+}
+
+// topCounts returns the n most frequent values, ties broken alphabetically
+func topCounts(counts map[string]int, n int) []Count {
+	var sorted []Count
+	for value, count := range counts {
+		sorted = append(sorted, Count{Value: value, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Value < sorted[j].Value
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// percentiles uses the nearest-rank method
+func percentiles(samples []time.Duration) Percentiles {
+	p := Percentiles{Samples: len(samples)}
+	if len(samples) == 0 {
+		return p
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	rank := func(q float64) time.Duration {
+		i := int(math.Ceil(q*float64(len(samples)))) - 1
+		return samples[max(i, 0)]
+	}
+
+	p.P50, p.P90, p.P95, p.P99 = rank(0.50), rank(0.90), rank(0.95), rank(0.99)
+	p.Max = samples[len(samples)-1]
+	return p
+}
@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarizeWindow(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	window := time.Minute
+
+	tests := []struct {
+		name    string
+		at      []time.Duration // record times before now
+		total   int
+		buckets []int
+	}{
+		{"exactly at the start of the window", []time.Duration{window}, 1, []int{1, 0, 0, 0}},
+		{"just before the window", []time.Duration{window + time.Nanosecond}, 0, []int{0, 0, 0, 0}},
+		{"exactly at now", []time.Duration{0}, 1, []int{0, 0, 0, 1}},
+		{"in the future", []time.Duration{-time.Second}, 0, []int{0, 0, 0, 0}},
+		{"on a bucket boundary", []time.Duration{45 * time.Second, 30 * time.Second}, 2, []int{0, 1, 1, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []*Record
+			for _, ago := range tt.at {
+				records = append(records, &Record{Time: now.Add(-ago), Status: 200})
+			}
+			stats := Summarize(records, window, now, 4, 5, false)
+			if stats.Total != tt.total {
+				t.Errorf("Total = %d, want %d", stats.Total, tt.total)
+			}
+			if !reflect.DeepEqual(stats.Buckets, tt.buckets) {
+				t.Errorf("Buckets = %v, want %v", stats.Buckets, tt.buckets)
+			}
+			if stats.Classes[2] != tt.total {
+				t.Errorf("Classes[2] = %d, want %d", stats.Classes[2], tt.total)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	record := func(ago time.Duration, status int, path, ip string, latency time.Duration) *Record {
+		return &Record{Time: now.Add(-ago), Status: status, Path: path, RemoteAddr: ip, RequestTime: latency, HasRequestTime: latency > 0}
+	}
+	records := []*Record{
+		record(50*time.Second, 200, "/", "10.0.0.1", 10*time.Millisecond),
+		record(40*time.Second, 200, "/", "10.0.0.2", 20*time.Millisecond),
+		record(30*time.Second, 404, "/missing", "10.0.0.1", 30*time.Millisecond),
+		record(20*time.Second, 502, "/api", "10.0.0.1", 0),
+		{Raw: "no time", Status: 500},
+	}
+
+	tests := []struct {
+		name    string
+		partial bool
+		span    time.Duration
+		perSec  float64
+	}{
+		{"whole log read", false, time.Minute, 4.0 / 60},
+		{"only the tail read", true, 50 * time.Second, 4.0 / 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := Summarize(records, time.Minute, now, 6, 2, tt.partial)
+
+			if stats.Total != 4 {
+				t.Errorf("Total = %d, want 4", stats.Total)
+			}
+			if stats.Span != tt.span || stats.PerSec != tt.perSec {
+				t.Errorf("Span, PerSec = %v, %v, want %v, %v", stats.Span, stats.PerSec, tt.span, tt.perSec)
+			}
+			if want := [6]int{0, 0, 2, 0, 1, 1}; stats.Classes != want {
+				t.Errorf("Classes = %v, want %v", stats.Classes, want)
+			}
+			if want := []int{0, 1, 1, 1, 1, 0}; !reflect.DeepEqual(stats.Buckets, want) {
+				t.Errorf("Buckets = %v, want %v", stats.Buckets, want)
+			}
+			if want := []Count{{"/", 2}, {"/api", 1}}; !reflect.DeepEqual(stats.Paths, want) {
+				t.Errorf("Paths = %v, want %v", stats.Paths, want)
+			}
+			if want := []Count{{"10.0.0.1", 3}, {"10.0.0.2", 1}}; !reflect.DeepEqual(stats.IPs, want) {
+				t.Errorf("IPs = %v, want %v", stats.IPs, want)
+			}
+			if stats.Latency.Samples != 3 || stats.Latency.P50 != 20*time.Millisecond || stats.Latency.Max != 30*time.Millisecond {
+				t.Errorf("Latency = %+v", stats.Latency)
+			}
+		})
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		want    Percentiles
+	}{
+		{"none", nil, Percentiles{}},
+		{"one", []time.Duration{time.Second}, Percentiles{1, time.Second, time.Second, time.Second, time.Second, time.Second}},
+		{"hundred", samples, Percentiles{100, 50 * time.Millisecond, 90 * time.Millisecond, 95 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		if got := percentiles(tt.samples); got != tt.want {
+			t.Errorf("%s: percentiles = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package nginxconf
+
+// Block is a block directive and the lines it spans
+type Block struct {
+	Name  string
+	Start int // 1-based line of the directive name
+	End   int // 1-based line of the closing brace, 0 if the block isn't closed
+}
+
+// Blocks returns every block of src, each before the blocks inside it.
+// Source that doesn't parse still gives the blocks lexed so far.
+func Blocks(src string) []Block {
+	var blocks []Block
+	var open []int // indices in blocks of the blocks not closed yet
+	var first *Token
+
+	tokens := Lex(src)
+	for i := range tokens {
+		tok := &tokens[i]
+		switch tok.Kind {
+		case TokenComment:
+			continue
+		case TokenOpenBrace:
+			// A brace without a name still pairs with its closing one
+			block := Block{Start: tok.Line}
+			if first != nil {
+				block = Block{Name: first.Value, Start: first.Line}
+			}
+			open = append(open, len(blocks))
+			blocks = append(blocks, block)
+			first = nil
+		case TokenCloseBrace:
+			if len(open) > 0 {
+				blocks[open[len(open)-1]].End = tok.Line
+				open = open[:len(open)-1]
+			}
+			first = nil
+		case TokenSemicolon:
+			first = nil
+		default:
+			if first == nil {
+				first = tok
+			}
+		}
+	}
+	return blocks
+}
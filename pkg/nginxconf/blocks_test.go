@@ -0,0 +1,53 @@
+package nginxconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Block
+	}{
+		{
+			name: "nested blocks, outer first",
+			src:  "http {\n  server {\n    location / {\n    }\n  }\n}",
+			want: []Block{{"http", 1, 6}, {"server", 2, 5}, {"location", 3, 4}},
+		},
+		{
+			name: "siblings",
+			src:  "events {}\nhttp {\n}",
+			want: []Block{{"events", 1, 1}, {"http", 2, 3}},
+		},
+		{
+			name: "name taken from the start of the directive",
+			src:  "user nginx;\n# a comment {\nlocation ~ \\.php$ {\n}",
+			want: []Block{{"location", 3, 4}},
+		},
+		{
+			name: "unclosed block",
+			src:  "http {\n  server {\n  }\n",
+			want: []Block{{"http", 1, 0}, {"server", 2, 3}},
+		},
+		{
+			name: "brace without a name",
+			src:  "{\n}",
+			want: []Block{{"", 1, 2}},
+		},
+		{
+			name: "no blocks",
+			src:  "user nginx;",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Blocks(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Blocks(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package nginxconf
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is an error or warning reported by nginx -t
+type Diagnostic struct {
+	Level   string // emerg, alert, crit, error, warn, notice, info or debug
+	Message string
+	File    string // "" when nginx names no position
+	Line    int
+}
+
+// diagnosticPattern matches "nginx: [emerg] message in /path:12", also in the
+// error log form "2024/01/02 10:00:00 [emerg] 123#123: message" nginx uses
+// once it has opened the error log
+var diagnosticPattern = regexp.MustCompile(`^(?:nginx: |\d{4}/\d\d/\d\d \d\d:\d\d:\d\d )\[(\w+)\] (?:\d+#\d+: )?(.*?)(?: in (\S+):(\d+))?$`)
+
+// ParseDiagnostics extracts the errors and warnings from nginx -t output.
+// The closing "syntax is ok" and "test failed" lines are left out.
+func ParseDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		m := diagnosticPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		d := Diagnostic{Level: m[1], Message: m[2], File: m[3]}
+		d.Line, _ = strconv.Atoi(m[4])
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
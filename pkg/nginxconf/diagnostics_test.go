@@ -0,0 +1,53 @@
+package nginxconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Diagnostic
+	}{
+		{
+			name: "failed test",
+			output: `nginx: [emerg] unknown directive "gzipp" in /etc/nginx/nginx.conf:12
+nginx: configuration file /etc/nginx/nginx.conf test failed
+`,
+			want: []Diagnostic{{Level: "emerg", Message: `unknown directive "gzipp"`, File: "/etc/nginx/nginx.conf", Line: 12}},
+		},
+		{
+			name: "warning on a passing test",
+			output: `nginx: [warn] conflicting server name "example.com" on 0.0.0.0:80, ignored
+nginx: the configuration file /etc/nginx/nginx.conf syntax is ok
+nginx: configuration file /etc/nginx/nginx.conf test is successful
+`,
+			want: []Diagnostic{{Level: "warn", Message: `conflicting server name "example.com" on 0.0.0.0:80, ignored`}},
+		},
+		{
+			name:   "error log form",
+			output: `2024/01/02 10:00:00 [emerg] 123#123: open() "/etc/nginx/missing.conf" failed (2: No such file or directory) in /etc/nginx/nginx.conf:30`,
+			want: []Diagnostic{{
+				Level:   "emerg",
+				Message: `open() "/etc/nginx/missing.conf" failed (2: No such file or directory)`,
+				File:    "/etc/nginx/nginx.conf",
+				Line:    30,
+			}},
+		},
+		{
+			name:   "nothing to report",
+			output: "nginx: the configuration file /etc/nginx/nginx.conf syntax is ok\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseDiagnostics(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDiagnostics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package nginxconf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxIncludeDepth bounds how deep includes nest. Loops (a.conf includes
+// b.conf includes a.conf) are cut as soon as a file comes round again.
+const maxIncludeDepth = 16
+
+// Tree is the effective configuration: the main file with every include
+// directive replaced by the directives of the files it matched.
+type Tree struct {
+	Main       string
+	Prefix     string
+	Files      []*Config    // every parsed file, in the order nginx reads them
+	Directives []*Directive // merged directives, includes expanded in place
+	Errors     []error      // problems with included files (missing, unreadable, syntax)
+
+	looping map[string]bool // files found to include themselves
+}
+
+// Resolve parses the main configuration file and follows its include
+// directives, including globs such as "sites-enabled/*" or "conf.d/*.conf".
+// Relative include paths are resolved against prefix, which defaults to the
+// directory of the main file like nginx's --conf-path prefix.
+// An error is only returned if the main file can't be read or parsed.
+func Resolve(mainPath string, prefix string) (*Tree, error) {
+	if prefix == "" {
+		prefix = filepath.Dir(mainPath)
+	}
+
+	main, err := ParseFile(mainPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{Main: mainPath, Prefix: prefix, Files: []*Config{main}, looping: make(map[string]bool)}
+	tree.Directives = tree.expand(main.Directives, []string{absPath(mainPath)})
+	return tree, nil
+}
+
+// expand returns a copy of directives with include directives replaced by
+// the content of the matching files. chain lists the files being included,
+// from the main file down to the one directives come from.
+func (t *Tree) expand(directives []*Directive, chain []string) []*Directive {
+	expanded := make([]*Directive, 0, len(directives))
+
+	for _, d := range directives {
+		if d.Name == "include" && len(d.Args) > 0 {
+			expanded = append(expanded, t.include(d, chain)...)
+			continue
+		}
+
+		if d.IsBlock() {
+			copied := *d
+			copied.Block = t.expand(d.Block, chain)
+			expanded = append(expanded, &copied)
+			continue
+		}
+
+		expanded = append(expanded, d)
+	}
+
+	return expanded
+}
+
+// include parses the files matched by a single include directive
+func (t *Tree) include(d *Directive, chain []string) []*Directive {
+	if len(chain) > maxIncludeDepth {
+		t.Errors = append(t.Errors, &ParseError{File: d.File, Line: d.Line, Msg: "include nesting too deep (include loop?)"})
+		return nil
+	}
+
+	files, err := t.IncludedFiles(d.Arg(0))
+	if err != nil {
+		t.Errors = append(t.Errors, &ParseError{File: d.File, Line: d.Line, Msg: err.Error()})
+		return nil
+	}
+
+	var directives []*Directive
+	for _, file := range files {
+		// A file including itself, directly or through others, e.g. with a
+		// glob that matches it, is skipped instead of read again. It isn't
+		// expanded anywhere else either: sibling files matched by the same
+		// glob would otherwise include each other in every order.
+		abs := absPath(file)
+		if t.looping[abs] {
+			continue
+		}
+		if slices.Contains(chain, abs) {
+			t.looping[abs] = true
+			t.Errors = append(t.Errors, &ParseError{File: d.File, Line: d.Line, Msg: fmt.Sprintf("include loop: \"%s\" is already being included", file)})
+			continue
+		}
+
+		conf, err := ParseFile(file)
+		if err != nil {
+			t.Errors = append(t.Errors, err)
+			continue
+		}
+		t.Files = append(t.Files, conf)
+		// The chain is copied so sibling includes don't share its backing array
+		directives = append(directives, t.expand(conf.Directives, append(slices.Clip(chain), abs))...)
+	}
+
+	return directives
+}
+
+// absPath returns path made absolute, so the same file compares equal
+// however an include names it
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// IncludedFiles returns the files an include argument refers to, sorted the
+// way nginx reads them. A pattern without glob characters must exist.
+func (t *Tree) IncludedFiles(pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(t.Prefix, pattern)
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("open() \"%s\" failed (%v)", pattern, err)
+		}
+		return []string{pattern}, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern \"%s\": %v", pattern, err)
+	}
+	sort.Strings(matches)
+
+	// Directories matched by a wildcard are skipped like nginx does
+	files := matches[:0]
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
+
+// File returns the parsed file with the given path, or nil
+func (t *Tree) File(path string) *Config {
+	for _, conf := range t.Files {
+		if conf.File == path {
+			return conf
+		}
+	}
+	return nil
+}
+
+// Position is a line of a configuration file
+type Position struct {
+	File string
+	Line int
+}
+
+// Dump renders the effective configuration the same way "nginx -T" does:
+// every file that was read, each preceded by a "# configuration file" marker.
+func (t *Tree) Dump() string {
+	dump, _ := t.DumpPositions()
+	return dump
+}
+
+// DumpPositions is Dump that also returns the file and line each line of
+// the dump comes from. Markers and the blank lines between files have a
+// zero Position.
+func (t *Tree) DumpPositions() (string, []Position) {
+	var s strings.Builder
+	var positions []Position
+	seen := make(map[string]bool)
+
+	for _, conf := range t.Files {
+		// nginx -T prints each file only once even if it is included twice
+		if seen[conf.File] {
+			continue
+		}
+		seen[conf.File] = true
+
+		s.WriteString(FileMarker(conf.File) + "\n")
+		positions = append(positions, Position{})
+		source := strings.TrimSuffix(conf.Source, "\n")
+		s.WriteString(source + "\n\n")
+		for i := range strings.Split(source, "\n") {
+			positions = append(positions, Position{File: conf.File, Line: i + 1})
+		}
+		positions = append(positions, Position{})
+	}
+
+	return s.String(), positions
+}
+
+// FileMarker is the comment line nginx -T puts before each file
+func FileMarker(path string) string {
+	return "# configuration file " + path + ":"
+}
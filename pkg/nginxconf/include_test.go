@@ -0,0 +1,104 @@
+package nginxconf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		names  []string // top-level directive names after expansion
+		parsed int      // parsed files, the main one included
+		errors []string // substrings of the expected errors, in order
+	}{
+		{
+			name: "glob in a block",
+			files: map[string]string{
+				"nginx.conf":           "http {\n  include sites/*.conf;\n}\n",
+				"sites/a.conf":         "server {}\n",
+				"sites/b.conf":         "server {}\n",
+				"sites/ignored.backup": "oops\n",
+			},
+			names:  []string{"http"},
+			parsed: 3,
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"nginx.conf": "include missing.conf;\nuser nginx;\n",
+			},
+			names:  []string{"user"},
+			parsed: 1,
+			errors: []string{"missing.conf"},
+		},
+		{
+			name: "file including itself",
+			files: map[string]string{
+				"nginx.conf": "include self.conf;\n",
+				"self.conf":  "user nginx;\ninclude self.conf;\n",
+			},
+			names:  []string{"user"},
+			parsed: 2,
+			errors: []string{"include loop"},
+		},
+		{
+			name: "siblings globbing each other",
+			files: map[string]string{
+				"nginx.conf":    "include conf.d/*.conf;\n",
+				"conf.d/a.conf": "include conf.d/*.conf;\n",
+				"conf.d/b.conf": "include conf.d/*.conf;\n",
+				"conf.d/c.conf": "include conf.d/*.conf;\n",
+			},
+			parsed: 4,
+			errors: []string{"include loop", "include loop", "include loop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			tree, err := Resolve(filepath.Join(dir, "nginx.conf"), "")
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+
+			var names []string
+			for _, d := range tree.Directives {
+				names = append(names, d.Name)
+			}
+			if strings.Join(names, " ") != strings.Join(tt.names, " ") {
+				t.Errorf("directives = %v, want %v", names, tt.names)
+			}
+			if len(tree.Files) != tt.parsed {
+				t.Errorf("parsed %d files, want %d", len(tree.Files), tt.parsed)
+			}
+			if len(tree.Errors) != len(tt.errors) {
+				t.Fatalf("errors = %v, want %d", tree.Errors, len(tt.errors))
+			}
+			for i, want := range tt.errors {
+				if !strings.Contains(tree.Errors[i].Error(), want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, tree.Errors[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package nginxconf
+
+import (
+	"strings"
+)
+
+// TokenKind identifies what a lexed token represents
+type TokenKind int
+
+const (
+	TokenWord       TokenKind = iota // directive name or argument
+	TokenString                      // quoted argument ("..." or '...')
+	TokenSemicolon                   // ;
+	TokenOpenBrace                   // {
+	TokenCloseBrace                  // }
+	TokenComment                     // # comment until end of line
+)
+
+// Token is a single lexical element of an nginx configuration file
+type Token struct {
+	Kind  TokenKind
+	Value string // unquoted/unescaped value (comment text without the leading #)
+	Line  int    // 1-based line where the token starts
+	Start int    // byte offset of the first character in the source
+	End   int    // byte offset just past the last character in the source
+}
+
+// Lex splits nginx configuration source into tokens.
+// It follows the same rules nginx uses: whitespace separates words, ';', '{'
+// and '}' are special, '#' starts a comment only at the beginning of a word,
+// quotes group words and '\' escapes the next character.
+// An unterminated quoted string is returned as a string token ending at EOF.
+func Lex(src string) []Token {
+	var tokens []Token
+	line := 1
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+
+		// Skip whitespace, counting lines
+		if c == '\n' {
+			line++
+			i++
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\r' {
+			i++
+			continue
+		}
+
+		switch c {
+		case '#':
+			start := i
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			tokens = append(tokens, Token{Kind: TokenComment, Value: strings.TrimRight(src[start+1:i], "\r"), Line: line, Start: start, End: i})
+			continue
+		case ';':
+			tokens = append(tokens, Token{Kind: TokenSemicolon, Value: ";", Line: line, Start: i, End: i + 1})
+			i++
+			continue
+		case '{':
+			tokens = append(tokens, Token{Kind: TokenOpenBrace, Value: "{", Line: line, Start: i, End: i + 1})
+			i++
+			continue
+		case '}':
+			tokens = append(tokens, Token{Kind: TokenCloseBrace, Value: "}", Line: line, Start: i, End: i + 1})
+			i++
+			continue
+		case '"', '\'':
+			start := i
+			startLine := line
+			quote := c
+			var value strings.Builder
+			i++
+			for i < len(src) && src[i] != quote {
+				if src[i] == '\\' && i+1 < len(src) {
+					// nginx only unescapes the quote character and backslash itself
+					next := src[i+1]
+					if next == quote || next == '\\' {
+						value.WriteByte(next)
+					} else {
+						value.WriteByte('\\')
+						value.WriteByte(next)
+					}
+					if next == '\n' {
+						line++
+					}
+					i += 2
+					continue
+				}
+				if src[i] == '\n' {
+					line++
+				}
+				value.WriteByte(src[i])
+				i++
+			}
+			if i < len(src) {
+				i++ // closing quote
+			}
+			tokens = append(tokens, Token{Kind: TokenString, Value: value.String(), Line: startLine, Start: start, End: i})
+			continue
+		}
+
+		// Plain word: runs until whitespace or a special character.
+		// "${var}" is kept together so the brace isn't taken for a block.
+		start := i
+		var value strings.Builder
+		for i < len(src) {
+			ch := src[i]
+			if ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n' || ch == ';' || ch == '{' || ch == '}' {
+				break
+			}
+			if ch == '\\' && i+1 < len(src) {
+				value.WriteByte(ch)
+				value.WriteByte(src[i+1])
+				i += 2
+				continue
+			}
+			if ch == '$' && i+1 < len(src) && src[i+1] == '{' {
+				// The name must close before the word ends; otherwise the
+				// "$" is plain and the brace opens a block
+				end := strings.IndexAny(src[i+2:], "} \t\r\n;")
+				if end >= 0 && src[i+2+end] == '}' {
+					value.WriteString(src[i : i+2+end+1])
+					i += 2 + end + 1
+					continue
+				}
+			}
+			value.WriteByte(ch)
+			i++
+		}
+		tokens = append(tokens, Token{Kind: TokenWord, Value: value.String(), Line: line, Start: start, End: i})
+	}
+
+	return tokens
+}
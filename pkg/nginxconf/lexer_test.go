@@ -0,0 +1,107 @@
+package nginxconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	type tok struct {
+		Kind  TokenKind
+		Value string
+		Line  int
+	}
+	tests := []struct {
+		name string
+		src  string
+		want []tok
+	}{
+		{
+			name: "directive",
+			src:  "listen 80;",
+			want: []tok{{TokenWord, "listen", 1}, {TokenWord, "80", 1}, {TokenSemicolon, ";", 1}},
+		},
+		{
+			name: "block over lines",
+			src:  "server {\n  listen 80;\n}",
+			want: []tok{
+				{TokenWord, "server", 1}, {TokenOpenBrace, "{", 1},
+				{TokenWord, "listen", 2}, {TokenWord, "80", 2}, {TokenSemicolon, ";", 2},
+				{TokenCloseBrace, "}", 3},
+			},
+		},
+		{
+			name: "quoted strings keep spaces and specials",
+			src:  `add_header X "a; b {c}" 'd';`,
+			want: []tok{{TokenWord, "add_header", 1}, {TokenWord, "X", 1}, {TokenString, "a; b {c}", 1}, {TokenString, "d", 1}, {TokenSemicolon, ";", 1}},
+		},
+		{
+			name: "escaped quote in a string",
+			src:  `return 200 "say \"hi\"";`,
+			want: []tok{{TokenWord, "return", 1}, {TokenWord, "200", 1}, {TokenString, `say "hi"`, 1}, {TokenSemicolon, ";", 1}},
+		},
+		{
+			name: "comment at the start of a word",
+			src:  "# top\nroot /srv; # trailing",
+			want: []tok{{TokenComment, " top", 1}, {TokenWord, "root", 2}, {TokenWord, "/srv", 2}, {TokenSemicolon, ";", 2}, {TokenComment, " trailing", 2}},
+		},
+		{
+			name: "hash inside a word is not a comment",
+			src:  "location /a#b;",
+			want: []tok{{TokenWord, "location", 1}, {TokenWord, "/a#b", 1}, {TokenSemicolon, ";", 1}},
+		},
+		{
+			name: "braced variable stays in the word",
+			src:  "return 200 ${host}x;",
+			want: []tok{{TokenWord, "return", 1}, {TokenWord, "200", 1}, {TokenWord, "${host}x", 1}, {TokenSemicolon, ";", 1}},
+		},
+		{
+			name: "dollar before a block brace",
+			src:  "location ~ ^/a${\n  root /srv;\n}",
+			want: []tok{
+				{TokenWord, "location", 1}, {TokenWord, "~", 1}, {TokenWord, "^/a$", 1}, {TokenOpenBrace, "{", 1},
+				{TokenWord, "root", 2}, {TokenWord, "/srv", 2}, {TokenSemicolon, ";", 2},
+				{TokenCloseBrace, "}", 3},
+			},
+		},
+		{
+			name: "braced variable cut by a semicolon",
+			src:  "set $a ${b;",
+			want: []tok{{TokenWord, "set", 1}, {TokenWord, "$a", 1}, {TokenWord, "$", 1}, {TokenOpenBrace, "{", 1}, {TokenWord, "b", 1}, {TokenSemicolon, ";", 1}},
+		},
+		{
+			name: "unterminated string runs to the end",
+			src:  `root "/srv`,
+			want: []tok{{TokenWord, "root", 1}, {TokenString, "/srv", 1}},
+		},
+		{
+			name: "empty source",
+			src:  "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []tok
+			for _, token := range Lex(tt.src) {
+				got = append(got, tok{token.Kind, token.Value, token.Line})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lex(%q) =\n%v\nwant\n%v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexOffsets(t *testing.T) {
+	src := `root "/srv";`
+	tokens := Lex(src)
+	if len(tokens) != 3 {
+		t.Fatalf("got %d tokens, want 3", len(tokens))
+	}
+	// Offsets cover the quotes, so highlighting can paint the source as written
+	if got := src[tokens[1].Start:tokens[1].End]; got != `"/srv"` {
+		t.Errorf("string token covers %q, want %q", got, `"/srv"`)
+	}
+}
@@ -0,0 +1,202 @@
+package nginxconf
+
+import (
+	"fmt"
+	"os"
+)
+
+// Directive is a node of the configuration AST.
+// A simple directive has a nil Block, a block directive (server, location, ...)
+// has its children in Block and a comment has Name "#" with the text in Comment.
+type Directive struct {
+	Name    string
+	Args    []string
+	Comment string
+	Block   []*Directive
+	File    string
+	Line    int
+	EndLine int // line of the closing brace for blocks, same as Line otherwise
+}
+
+// IsBlock reports whether the directive opens a { ... } block
+func (d *Directive) IsBlock() bool {
+	return d.Block != nil
+}
+
+// IsComment reports whether the node is a comment
+func (d *Directive) IsComment() bool {
+	return d.Name == "#"
+}
+
+// Arg returns the i-th argument or an empty string if it is missing
+func (d *Directive) Arg(i int) string {
+	if i < len(d.Args) {
+		return d.Args[i]
+	}
+	return ""
+}
+
+// Config is the parsed content of a single configuration file
+type Config struct {
+	File       string
+	Source     string
+	Directives []*Directive
+}
+
+// ParseError describes a syntax error with its position
+type ParseError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// ParseFile reads and parses a single configuration file.
+// Include directives are kept as plain directives and not followed.
+func ParseFile(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, string(content))
+}
+
+// Parse parses configuration source. The file name is only used for positions.
+func Parse(file string, src string) (*Config, error) {
+	p := &parser{file: file, tokens: Lex(src)}
+	directives, err := p.parseBlock(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{File: file, Source: src, Directives: directives}, nil
+}
+
+type parser struct {
+	file   string
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) errorf(line int, format string, args ...interface{}) error {
+	return &ParseError{File: p.file, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseBlock parses directives until EOF (top level) or the matching '}'
+func (p *parser) parseBlock(inBlock bool) ([]*Directive, error) {
+	directives := []*Directive{}
+
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		p.pos++
+
+		switch tok.Kind {
+		case TokenComment:
+			directives = append(directives, &Directive{Name: "#", Comment: tok.Value, File: p.file, Line: tok.Line, EndLine: tok.Line})
+			continue
+		case TokenCloseBrace:
+			if !inBlock {
+				return nil, p.errorf(tok.Line, "unexpected \"}\"")
+			}
+			return directives, nil
+		case TokenSemicolon:
+			return nil, p.errorf(tok.Line, "unexpected \";\"")
+		case TokenOpenBrace:
+			return nil, p.errorf(tok.Line, "unexpected \"{\"")
+		}
+
+		d := &Directive{Name: tok.Value, Args: []string{}, File: p.file, Line: tok.Line, EndLine: tok.Line}
+
+		// Collect arguments until ';' or '{'. Comments between arguments
+		// are attached after the directive so they aren't lost.
+		var trailing []*Directive
+		terminated := false
+		for p.pos < len(p.tokens) && !terminated {
+			arg := p.tokens[p.pos]
+			p.pos++
+
+			switch arg.Kind {
+			case TokenWord, TokenString:
+				d.Args = append(d.Args, arg.Value)
+			case TokenComment:
+				trailing = append(trailing, &Directive{Name: "#", Comment: arg.Value, File: p.file, Line: arg.Line, EndLine: arg.Line})
+			case TokenSemicolon:
+				d.EndLine = arg.Line
+				terminated = true
+			case TokenOpenBrace:
+				children, err := p.parseBlock(true)
+				if err != nil {
+					return nil, err
+				}
+				d.Block = children
+				d.EndLine = p.tokens[p.pos-1].Line
+				terminated = true
+			case TokenCloseBrace:
+				return nil, p.errorf(arg.Line, "directive \"%s\" is not terminated by \";\"", d.Name)
+			}
+		}
+
+		if !terminated {
+			return nil, p.errorf(tok.Line, "unexpected end of file, expecting \";\" or \"}\"")
+		}
+
+		directives = append(directives, d)
+		directives = append(directives, trailing...)
+	}
+
+	if inBlock {
+		line := 1
+		if len(p.tokens) > 0 {
+			line = p.tokens[len(p.tokens)-1].Line
+		}
+		return nil, p.errorf(line, "unexpected end of file, expecting \"}\"")
+	}
+
+	return directives, nil
+}
+
+// Walk visits every directive depth-first. The parents slice holds the
+// enclosing block directives, outermost first. Returning false from fn
+// skips the children of that directive.
+func Walk(directives []*Directive, fn func(d *Directive, parents []*Directive) bool) {
+	walk(directives, nil, fn)
+}
+
+func walk(directives []*Directive, parents []*Directive, fn func(d *Directive, parents []*Directive) bool) {
+	for _, d := range directives {
+		if !fn(d, parents) {
+			continue
+		}
+		if d.IsBlock() {
+			walk(d.Block, append(parents[:len(parents):len(parents)], d), fn)
+		}
+	}
+}
+
+// Find returns all directives with the given name at any depth
+func Find(directives []*Directive, name string) []*Directive {
+	var found []*Directive
+	Walk(directives, func(d *Directive, parents []*Directive) bool {
+		if d.Name == name {
+			found = append(found, d)
+		}
+		return true
+	})
+	return found
+}
+
+// Children returns the direct children of a block with the given name
+func (d *Directive) Children(name string) []*Directive {
+	var found []*Directive
+	for _, child := range d.Block {
+		if child.Name == name {
+			found = append(found, child)
+		}
+	}
+	return found
+}
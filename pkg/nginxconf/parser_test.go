@@ -0,0 +1,85 @@
+package nginxconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `# main
+user nginx;
+http {
+    server {
+        listen 80; # port
+        server_name example.com www.example.com;
+    }
+}
+`
+	conf, err := Parse("nginx.conf", src)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if len(conf.Directives) != 3 {
+		t.Fatalf("got %d top-level directives, want 3", len(conf.Directives))
+	}
+	comment, user, http := conf.Directives[0], conf.Directives[1], conf.Directives[2]
+	if !comment.IsComment() || comment.Comment != " main" {
+		t.Errorf("first directive = %+v, want the comment \" main\"", comment)
+	}
+	if user.Name != "user" || user.Arg(0) != "nginx" || user.Arg(1) != "" || user.IsBlock() {
+		t.Errorf("user directive = %+v", user)
+	}
+	if !http.IsBlock() || http.Line != 3 || http.EndLine != 8 {
+		t.Errorf("http block = line %d-%d, block %v, want 3-8", http.Line, http.EndLine, http.IsBlock())
+	}
+
+	server := http.Children("server")
+	if len(server) != 1 || server[0].Line != 4 || server[0].EndLine != 7 {
+		t.Fatalf("server blocks = %+v", server)
+	}
+	var names []string
+	for _, d := range server[0].Block {
+		names = append(names, d.Name)
+	}
+	// The trailing comment follows the directive it ends
+	if want := []string{"listen", "#", "server_name"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("server children = %v, want %v", names, want)
+	}
+	if got := server[0].Children("server_name")[0].Args; !reflect.DeepEqual(got, []string{"example.com", "www.example.com"}) {
+		t.Errorf("server_name args = %v", got)
+	}
+	if got := len(Find(conf.Directives, "listen")); got != 1 {
+		t.Errorf("Find listen = %d directives, want 1", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"stray closing brace", "user nginx;\n}", `nginx.conf:2: unexpected "}"`},
+		{"stray semicolon", ";", `nginx.conf:1: unexpected ";"`},
+		{"stray opening brace", "{", `nginx.conf:1: unexpected "{"`},
+		{"missing semicolon before brace", "http {\n  gzip on\n}", `nginx.conf:3: directive "gzip" is not terminated by ";"`},
+		{"unclosed block", "http {\n  gzip on;\n", `nginx.conf:2: unexpected end of file, expecting "}"`},
+		{"unterminated directive", "user nginx", `nginx.conf:1: unexpected end of file, expecting ";" or "}"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("nginx.conf", tt.src)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want %q", tt.src, tt.want)
+			}
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("error is %T, want *ParseError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Parse(%q) error = %q, want %q", tt.src, err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package nginxconf
+
+import (
+	"strings"
+)
+
+// Server is a summary of a server block
+type Server struct {
+	File           string
+	Line           int
+	Listen         []string // each listen directive, e.g. "443 ssl http2"
+	ServerNames    []string
+	Root           string
+	Certificate    string // ssl_certificate
+	CertificateKey string // ssl_certificate_key
+	AccessLogs     []string
+	ErrorLogs      []string
+	Actions        []Action // server-level actions, e.g. "return 301 https://$host$request_uri"
+	Locations      []Location
+}
+
+// Location is a summary of a location block
+type Location struct {
+	Match   string   // modifier and path, e.g. "~ \.php$" or "/api"
+	Line    int      // line of the location directive
+	Actions []Action // what the location does
+}
+
+// Action is a directive that decides how a location handles requests
+type Action struct {
+	Directive string // proxy_pass, fastcgi_pass, try_files or return
+	Args      []string
+	Line      int
+}
+
+// actionDirectives are the directives reported as what a location does
+var actionDirectives = map[string]bool{
+	"proxy_pass":   true,
+	"fastcgi_pass": true,
+	"uwsgi_pass":   true,
+	"grpc_pass":    true,
+	"try_files":    true,
+	"return":       true,
+}
+
+// String renders the action as it appears in the config, without ';'
+func (a Action) String() string {
+	return strings.TrimSpace(a.Directive + " " + strings.Join(a.Args, " "))
+}
+
+// Servers returns a summary of every server block in directives.
+// Nested locations are flattened in file order.
+func Servers(directives []*Directive) []Server {
+	var servers []Server
+
+	Walk(directives, func(d *Directive, parents []*Directive) bool {
+		if d.Name != "server" || !d.IsBlock() {
+			return true
+		}
+		// server blocks inside upstream {} are backends, not virtual hosts
+		if len(parents) > 0 && parents[len(parents)-1].Name == "upstream" {
+			return false
+		}
+		servers = append(servers, summarizeServer(d))
+		return false
+	})
+
+	return servers
+}
+
+func summarizeServer(d *Directive) Server {
+	server := Server{File: d.File, Line: d.Line}
+
+	for _, child := range d.Block {
+		switch child.Name {
+		case "listen":
+			server.Listen = append(server.Listen, strings.Join(child.Args, " "))
+		case "server_name":
+			server.ServerNames = append(server.ServerNames, child.Args...)
+		case "root":
+			server.Root = child.Arg(0)
+		case "ssl_certificate":
+			server.Certificate = child.Arg(0)
+		case "ssl_certificate_key":
+			server.CertificateKey = child.Arg(0)
+		case "access_log":
+			server.AccessLogs = append(server.AccessLogs, child.Arg(0))
+		case "error_log":
+			server.ErrorLogs = append(server.ErrorLogs, child.Arg(0))
+		case "location":
+			server.Locations = append(server.Locations, summarizeLocations(child)...)
+		default:
+			server.Actions = append(server.Actions, actions(child)...)
+		}
+	}
+
+	return server
+}
+
+// summarizeLocations returns the location and every location nested in it
+func summarizeLocations(d *Directive) []Location {
+	location := Location{Match: strings.Join(d.Args, " "), Line: d.Line}
+	var nested []Location
+
+	for _, child := range d.Block {
+		if child.Name == "location" {
+			nested = append(nested, summarizeLocations(child)...)
+			continue
+		}
+		location.Actions = append(location.Actions, actions(child)...)
+	}
+
+	return append([]Location{location}, nested...)
+}
+
+// actions returns d if it is an action directive, or the actions of an
+// "if" block, which is where conditional returns usually live
+func actions(d *Directive) []Action {
+	if actionDirectives[d.Name] {
+		return []Action{{Directive: d.Name, Args: d.Args, Line: d.Line}}
+	}
+
+	var found []Action
+	if d.Name == "if" {
+		for _, child := range d.Block {
+			found = append(found, actions(child)...)
+		}
+	}
+	return found
+}
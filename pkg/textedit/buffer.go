@@ -0,0 +1,406 @@
+package textedit
+
+import (
+	"lazynginx/pkg/nginxconf"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxUndo is how many edits Undo can go back
+const maxUndo = 500
+
+// state is the text and cursor of a buffer, as kept for undo
+type state struct {
+	text     string
+	row, col int
+}
+
+// Buffer is the text of a file being edited with a cursor and an undo
+// history. Columns are byte offsets into the line.
+type Buffer struct {
+	lines    []string
+	row, col int
+	goal     int // rune column kept across up/down over shorter lines
+
+	undo, redo []state
+	group      string // kind of the last edit; typing in a row undoes as one step
+	saved      string // text last written to disk
+
+	Top  int // first line shown
+	Left int // first column shown
+}
+
+// New returns a buffer holding text with the cursor at the top
+func New(text string) *Buffer {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return &Buffer{lines: strings.Split(text, "\n"), saved: text}
+}
+
+// String returns the text of the buffer
+func (b *Buffer) String() string {
+	return strings.Join(b.lines, "\n")
+}
+
+// Lines returns the lines of the buffer
+func (b *Buffer) Lines() []string {
+	return b.lines
+}
+
+// Cursor returns the line and byte column of the cursor, both from 0
+func (b *Buffer) Cursor() (row int, col int) {
+	return b.row, b.col
+}
+
+// Column returns the 1-based rune column of the cursor, as shown to the user
+func (b *Buffer) Column() int {
+	return utf8.RuneCountInString(b.lines[b.row][:b.col]) + 1
+}
+
+// Modified reports whether the text differs from what was last saved
+func (b *Buffer) Modified() bool {
+	return b.String() != b.saved
+}
+
+// MarkSaved records text as written to disk. It is the text the save
+// started with; edits made while it ran still count as modified.
+func (b *Buffer) MarkSaved(text string) {
+	b.saved = text
+}
+
+// GoToLine moves the cursor to the start of a 1-based line
+func (b *Buffer) GoToLine(line int) {
+	b.row = max(min(line-1, len(b.lines)-1), 0)
+	b.col = 0
+	b.goal = 0
+	b.group = ""
+}
+
+// MoveLeft moves the cursor one character back, to the end of the previous line at the start of a line
+func (b *Buffer) MoveLeft() {
+	if b.col > 0 {
+		_, size := utf8.DecodeLastRuneInString(b.lines[b.row][:b.col])
+		b.col -= size
+	} else if b.row > 0 {
+		b.row--
+		b.col = len(b.lines[b.row])
+	}
+	b.moved()
+}
+
+// MoveRight moves the cursor one character on, to the next line at the end of a line
+func (b *Buffer) MoveRight() {
+	if b.col < len(b.lines[b.row]) {
+		_, size := utf8.DecodeRuneInString(b.lines[b.row][b.col:])
+		b.col += size
+	} else if b.row < len(b.lines)-1 {
+		b.row++
+		b.col = 0
+	}
+	b.moved()
+}
+
+// MoveUp moves the cursor n lines up, keeping its column where the line allows
+func (b *Buffer) MoveUp(n int) {
+	b.row = max(b.row-n, 0)
+	b.toGoal()
+}
+
+// MoveDown moves the cursor n lines down, keeping its column where the line allows
+func (b *Buffer) MoveDown(n int) {
+	b.row = min(b.row+n, len(b.lines)-1)
+	b.toGoal()
+}
+
+// Home moves the cursor to the first non-blank character of the line, or
+// to the start of the line when it is there already
+func (b *Buffer) Home() {
+	line := b.lines[b.row]
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if b.col == indent {
+		indent = 0
+	}
+	b.col = indent
+	b.moved()
+}
+
+// End moves the cursor to the end of the line
+func (b *Buffer) End() {
+	b.col = len(b.lines[b.row])
+	b.moved()
+}
+
+// Start moves the cursor to the start of the text
+func (b *Buffer) Start() {
+	b.row, b.col = 0, 0
+	b.moved()
+}
+
+// Finish moves the cursor to the end of the text
+func (b *Buffer) Finish() {
+	b.row = len(b.lines) - 1
+	b.col = len(b.lines[b.row])
+	b.moved()
+}
+
+// moved ends the current undo group and remembers the column for up/down
+func (b *Buffer) moved() {
+	b.goal = utf8.RuneCountInString(b.lines[b.row][:b.col])
+	b.group = ""
+}
+
+// toGoal puts the cursor at the remembered column of the current line
+func (b *Buffer) toGoal() {
+	line := b.lines[b.row]
+	b.col = 0
+	for i := 0; i < b.goal && b.col < len(line); i++ {
+		_, size := utf8.DecodeRuneInString(line[b.col:])
+		b.col += size
+	}
+	b.group = ""
+}
+
+// Insert types text at the cursor. Newlines split the line.
+func (b *Buffer) Insert(text string) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	if text == "" {
+		return
+	}
+	kind := "type"
+	if strings.Contains(text, "\n") || len(text) > 1 {
+		kind = "paste"
+	}
+	b.record(kind)
+
+	line := b.lines[b.row]
+	inserted := strings.Split(line[:b.col]+text+line[b.col:], "\n")
+	last := inserted[len(inserted)-1]
+	b.lines = append(b.lines[:b.row], append(inserted, b.lines[b.row+1:]...)...)
+	b.row += len(inserted) - 1
+	b.col = len(last) - len(line[b.col:])
+	b.goal = utf8.RuneCountInString(b.lines[b.row][:b.col])
+}
+
+// Type inserts a typed character. A closing brace on a blank line is
+// moved back one indent level to line up with its block.
+func (b *Buffer) Type(text string) {
+	line := b.lines[b.row]
+	if text == "}" && strings.TrimSpace(line) == "" && b.col == len(line) {
+		b.record("type")
+		b.lines[b.row] = strings.TrimSuffix(line, b.indentUnit())
+		b.col = len(b.lines[b.row])
+	}
+	b.Insert(text)
+}
+
+// Newline splits the line at the cursor. The new line gets the indentation
+// of the current one, one level deeper after an opening brace.
+func (b *Buffer) Newline() {
+	line := b.lines[b.row]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	if strings.HasSuffix(strings.TrimRight(line[:b.col], " \t"), "{") {
+		indent += b.indentUnit()
+	}
+	b.record("newline")
+	b.group = ""
+	rest := strings.TrimLeft(line[b.col:], " \t")
+	b.lines[b.row] = strings.TrimRight(line[:b.col], " \t")
+	b.lines = append(b.lines[:b.row+1], append([]string{indent + rest}, b.lines[b.row+1:]...)...)
+	b.row++
+	b.col = len(indent)
+	b.goal = utf8.RuneCountInString(indent)
+}
+
+// Indent inserts one indentation level at the cursor
+func (b *Buffer) Indent() {
+	b.Insert(b.indentUnit())
+}
+
+// indentUnit returns the indentation the file uses: a tab when a line is
+// indented with one, else four spaces
+func (b *Buffer) indentUnit() string {
+	for _, line := range b.lines {
+		if strings.HasPrefix(line, "\t") {
+			return "\t"
+		}
+	}
+	return "    "
+}
+
+// Backspace deletes the character before the cursor, joining the line to
+// the previous one at its start
+func (b *Buffer) Backspace() {
+	if b.col == 0 && b.row == 0 {
+		return
+	}
+	b.record("delete")
+	if b.col == 0 {
+		prev := b.lines[b.row-1]
+		b.lines[b.row-1] = prev + b.lines[b.row]
+		b.lines = append(b.lines[:b.row], b.lines[b.row+1:]...)
+		b.row--
+		b.col = len(prev)
+	} else {
+		line := b.lines[b.row]
+		_, size := utf8.DecodeLastRuneInString(line[:b.col])
+		b.lines[b.row] = line[:b.col-size] + line[b.col:]
+		b.col -= size
+	}
+	b.goal = utf8.RuneCountInString(b.lines[b.row][:b.col])
+}
+
+// Delete deletes the character under the cursor, joining the next line at the end of a line
+func (b *Buffer) Delete() {
+	line := b.lines[b.row]
+	if b.col == len(line) && b.row == len(b.lines)-1 {
+		return
+	}
+	b.record("delete")
+	if b.col == len(line) {
+		b.lines[b.row] = line + b.lines[b.row+1]
+		b.lines = append(b.lines[:b.row+1], b.lines[b.row+2:]...)
+	} else {
+		_, size := utf8.DecodeRuneInString(line[b.col:])
+		b.lines[b.row] = line[:b.col] + line[b.col+size:]
+	}
+}
+
+// record saves the state before an edit for Undo. Edits of the same kind in
+// a row, like typing a word, are undone together.
+func (b *Buffer) record(kind string) {
+	b.redo = nil
+	if kind == b.group && kind != "paste" {
+		return
+	}
+	b.group = kind
+	b.undo = append(b.undo, b.state())
+	if len(b.undo) > maxUndo {
+		b.undo = b.undo[1:]
+	}
+}
+
+func (b *Buffer) state() state {
+	return state{text: b.String(), row: b.row, col: b.col}
+}
+
+func (b *Buffer) restore(s state) {
+	b.lines = strings.Split(s.text, "\n")
+	b.row, b.col = s.row, s.col
+	b.moved()
+}
+
+// Undo reverts the last edit, false if there is none
+func (b *Buffer) Undo() bool {
+	if len(b.undo) == 0 {
+		return false
+	}
+	b.redo = append(b.redo, b.state())
+	b.restore(b.undo[len(b.undo)-1])
+	b.undo = b.undo[:len(b.undo)-1]
+	return true
+}
+
+// Redo applies the last undone edit again, false if there is none
+func (b *Buffer) Redo() bool {
+	if len(b.redo) == 0 {
+		return false
+	}
+	b.undo = append(b.undo, b.state())
+	b.restore(b.redo[len(b.redo)-1])
+	b.redo = b.redo[:len(b.redo)-1]
+	return true
+}
+
+// Position is a place in the buffer: a line and byte column, both from 0
+type Position struct {
+	Row, Col int
+}
+
+// MatchBrace finds the brace under the cursor, or just before it, and the
+// brace it pairs with. Braces in comments and quoted strings don't count,
+// as the config is read the way nginx lexes it. ok is false when the cursor
+// isn't at a brace; a brace without a partner is returned with itself as
+// the match.
+func (b *Buffer) MatchBrace() (brace Position, match Position, ok bool) {
+	text := b.String()
+	offset := b.offset()
+
+	var stack []nginxconf.Token
+	pairs := map[int]int{}
+	var braces []nginxconf.Token
+	for _, tok := range nginxconf.Lex(text) {
+		switch tok.Kind {
+		case nginxconf.TokenOpenBrace:
+			stack = append(stack, tok)
+			braces = append(braces, tok)
+		case nginxconf.TokenCloseBrace:
+			braces = append(braces, tok)
+			if len(stack) > 0 {
+				open := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				pairs[open.Start] = tok.Start
+				pairs[tok.Start] = open.Start
+			}
+		}
+	}
+
+	for _, at := range []int{offset, offset - 1} {
+		for _, tok := range braces {
+			if tok.Start != at {
+				continue
+			}
+			other, paired := pairs[at]
+			if !paired {
+				other = at
+			}
+			return b.position(at), b.position(other), true
+		}
+	}
+	return Position{}, Position{}, false
+}
+
+// offset returns the byte offset of the cursor in String
+func (b *Buffer) offset() int {
+	offset := b.col
+	for _, line := range b.lines[:b.row] {
+		offset += len(line) + 1
+	}
+	return offset
+}
+
+// position turns a byte offset in String into a line and column
+func (b *Buffer) position(offset int) Position {
+	for row, line := range b.lines {
+		if offset <= len(line) {
+			return Position{Row: row, Col: offset}
+		}
+		offset -= len(line) + 1
+	}
+	return Position{Row: len(b.lines) - 1, Col: len(b.lines[len(b.lines)-1])}
+}
+
+// ScrollTo moves Top and Left so the cursor is inside a view of the given
+// number of lines and columns. Columns count tabs as width cells wide.
+func (b *Buffer) ScrollTo(lines int, columns int, tab int) {
+	lines = max(lines, 1)
+	columns = max(columns, 1)
+	if b.row < b.Top {
+		b.Top = b.row
+	}
+	if b.row >= b.Top+lines {
+		b.Top = b.row - lines + 1
+	}
+	b.Top = max(min(b.Top, len(b.lines)-lines), 0)
+
+	x := DisplayWidth(b.lines[b.row][:b.col], tab)
+	if x < b.Left {
+		b.Left = x
+	}
+	if x >= b.Left+columns {
+		b.Left = x - columns + 1
+	}
+}
+
+// DisplayWidth returns how many cells text takes on screen with tabs tab cells wide
+func DisplayWidth(text string, tab int) int {
+	return utf8.RuneCountInString(text) + strings.Count(text, "\t")*(tab-1)
+}
@@ -0,0 +1,130 @@
+package textedit
+
+import "testing"
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		edit  func(b *Buffer)
+		undos int    // how many times Undo is called
+		want  string // text after undoing
+	}{
+		{
+			name:  "typing in a row is one step",
+			text:  "",
+			edit:  func(b *Buffer) { b.Type("a"); b.Type("b"); b.Type("c") },
+			undos: 1,
+			want:  "",
+		},
+		{
+			name:  "newline starts a new step",
+			text:  "",
+			edit:  func(b *Buffer) { b.Type("a"); b.Newline(); b.Type("b") },
+			undos: 1,
+			want:  "a\n",
+		},
+		{
+			name:  "deleting after typing is a new step",
+			text:  "",
+			edit:  func(b *Buffer) { b.Type("a"); b.Type("b"); b.Backspace() },
+			undos: 1,
+			want:  "ab",
+		},
+		{
+			name:  "every paste is its own step",
+			text:  "x",
+			edit:  func(b *Buffer) { b.Insert("one "); b.Insert("two ") },
+			undos: 1,
+			want:  "one x",
+		},
+		{
+			name:  "undo everything",
+			text:  "x",
+			edit:  func(b *Buffer) { b.Type("a"); b.Newline(); b.Backspace(); b.Type("b") },
+			undos: 10,
+			want:  "x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.text)
+			tt.edit(b)
+			edited := b.String()
+
+			for i := 0; i < tt.undos; i++ {
+				b.Undo()
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("after %d undos text = %q, want %q", tt.undos, got, tt.want)
+			}
+
+			for b.Redo() {
+			}
+			if got := b.String(); got != edited {
+				t.Errorf("after redoing text = %q, want %q", got, edited)
+			}
+		})
+	}
+}
+
+func TestUndoRestoresCursor(t *testing.T) {
+	b := New("server {\n}")
+	b.GoToLine(1)
+	b.End()
+	b.Newline()
+	b.Type("listen 80;")
+
+	if row, col := b.Cursor(); row != 1 || col != 14 {
+		t.Fatalf("cursor after typing = %d:%d, want 1:14", row, col)
+	}
+	b.Undo()
+	if row, col := b.Cursor(); row != 1 || col != 4 {
+		t.Errorf("cursor after undoing the typing = %d:%d, want 1:4", row, col)
+	}
+	b.Undo()
+	if row, col := b.Cursor(); row != 0 || col != 8 {
+		t.Errorf("cursor after undoing the newline = %d:%d, want 0:8", row, col)
+	}
+	if b.Undo() {
+		t.Error("Undo with no edits left returned true")
+	}
+	if b.Modified() {
+		t.Errorf("buffer is modified after undoing everything: %q", b.String())
+	}
+}
+
+func TestMatchBrace(t *testing.T) {
+	text := "http {\n    server {\n        return 200 \"{\"; # }\n    }\n}\n}"
+	tests := []struct {
+		name  string
+		row   int
+		col   int
+		brace Position
+		match Position
+		ok    bool
+	}{
+		{"on an opening brace", 0, 5, Position{0, 5}, Position{4, 0}, true},
+		{"just after an opening brace", 0, 6, Position{0, 5}, Position{4, 0}, true},
+		{"on a closing brace", 3, 4, Position{3, 4}, Position{1, 11}, true},
+		{"brace without a partner", 5, 0, Position{5, 0}, Position{5, 0}, true},
+		{"brace in a string", 2, 20, Position{}, Position{}, false},
+		{"brace in a comment", 2, 26, Position{}, Position{}, false},
+		{"not at a brace", 0, 2, Position{}, Position{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(text)
+			b.GoToLine(tt.row + 1)
+			for i := 0; i < tt.col; i++ {
+				b.MoveRight()
+			}
+			brace, match, ok := b.MatchBrace()
+			if ok != tt.ok || brace != tt.brace || match != tt.match {
+				t.Errorf("MatchBrace() = %v, %v, %v, want %v, %v, %v", brace, match, ok, tt.brace, tt.match, tt.ok)
+			}
+		})
+	}
+}